@@ -0,0 +1,181 @@
+package sse
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	digest "github.com/Soontao/goHttpDigestClient"
+	"github.com/grafana/sobek"
+)
+
+var errDigestBodyNotReplayable = errors.New("digest auth requires a body which can be sent twice, files and streamed bodies are not supported")
+
+// Authentication schemes supported by the auth param.
+const (
+	authBasic  = "basic"
+	authDigest = "digest"
+	authBearer = "bearer"
+)
+
+// authOptions are the credentials of the stream. Without username,
+// basic and digest credentials are taken from the url as in k6/http.
+type authOptions struct {
+	scheme   string
+	username string
+	password string
+	token    string
+}
+
+// apply sets the credentials on the request and returns the transport to send it with.
+func (a *authOptions) apply(req *http.Request, transport http.RoundTripper) http.RoundTripper {
+	if a == nil {
+		return transport
+	}
+
+	username, password := a.username, a.password
+	if username == "" && req.URL.User != nil {
+		username = req.URL.User.Username()
+		password, _ = req.URL.User.Password()
+	}
+
+	switch a.scheme {
+	case authBasic:
+		req.SetBasicAuth(username, password)
+	case authDigest:
+		// Prevent the http client from sending the url credentials with basic auth
+		req.URL.User = nil
+		return &digestTransport{transport: transport, username: username, password: password}
+	case authBearer:
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	}
+
+	return transport
+}
+
+// checkCredentials returns an error if basic or digest auth has neither a username in the params
+// nor credentials in the urls, so no empty Authorization header is sent.
+func (a *authOptions) checkCredentials(urls ...string) error {
+	if a == nil || a.username != "" || (a.scheme != authBasic && a.scheme != authDigest) {
+		return nil
+	}
+	for _, rawURL := range urls {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return err
+		}
+		if u.User == nil || u.User.Username() == "" {
+			return fmt.Errorf("%s auth requires a username, in the params or the url", a.scheme)
+		}
+	}
+	return nil
+}
+
+// digestTransport answers the digest challenge of the server, so two requests are sent
+// as with the k6/http digest auth.
+type digestTransport struct {
+	transport http.RoundTripper
+	username  string
+	password  string
+}
+
+// RoundTrip sends the request without credentials, then with the authorization
+// computed from the challenge if the server responded with 401.
+func (t *digestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return nil, errDigestBodyNotReplayable
+	}
+
+	noAuthReq := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		noAuthReq.Body = body
+	}
+
+	noAuthResp, err := t.transport.RoundTrip(noAuthReq)
+	if err != nil || noAuthResp.StatusCode != http.StatusUnauthorized {
+		return noAuthResp, err
+	}
+
+	respBody, err := io.ReadAll(noAuthResp.Body)
+	if err != nil {
+		return nil, err
+	}
+	_ = noAuthResp.Body.Close()
+
+	challenge := digest.GetChallengeFromHeader(&noAuthResp.Header)
+	challenge.ComputeResponse(req.Method, req.URL.RequestURI(), string(respBody), t.username, t.password)
+
+	authReq := req.Clone(req.Context())
+	authReq.Header.Set(digest.KEY_AUTHORIZATION, challenge.ToAuthorizationStr())
+	return t.transport.RoundTrip(authReq)
+}
+
+// CloseIdleConnections closes the idle connections of the wrapped transport, so closing the client releases them.
+func (t *digestTransport) CloseIdleConnections() {
+	if transport, ok := t.transport.(interface{ CloseIdleConnections() }); ok {
+		transport.CloseIdleConnections()
+	}
+}
+
+// headersFromProvider returns the headers returned by the provider function,
+// called on the JS thread before each connection so expiring tokens can be refreshed.
+func headersFromProvider(rt *sobek.Runtime, provider sobek.Callable) (http.Header, error) {
+	v, err := provider(sobek.Undefined())
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(http.Header)
+	if sobek.IsUndefined(v) || sobek.IsNull(v) {
+		return headers, nil
+	}
+	obj := v.ToObject(rt)
+	for _, key := range obj.Keys() {
+		headers.Set(key, obj.Get(key).String())
+	}
+	return headers, nil
+}
+
+func parseAuthOptions(authV sobek.Value) (*authOptions, error) {
+	opts := &authOptions{}
+
+	if authObj, ok := authV.(*sobek.Object); ok {
+		for _, k := range authObj.Keys() {
+			v := authObj.Get(k)
+			if sobek.IsUndefined(v) || sobek.IsNull(v) {
+				continue
+			}
+			switch k {
+			case "type":
+				opts.scheme = strings.ToLower(strings.TrimSpace(v.String()))
+			case "username":
+				opts.username = v.String()
+			case "password":
+				opts.password = v.String()
+			case "token":
+				opts.token = v.String()
+			}
+		}
+	} else {
+		opts.scheme = strings.ToLower(strings.TrimSpace(authV.ToString().String()))
+	}
+
+	switch opts.scheme {
+	case authBasic, authDigest:
+	case authBearer:
+		if opts.token == "" {
+			return nil, errors.New("bearer auth requires a token")
+		}
+	default:
+		return nil, fmt.Errorf("unknown auth type %q", opts.scheme)
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,114 @@
+package sse
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/sobek"
+)
+
+// Defaults of the batch param.
+const (
+	defaultBatchMaxEvents = 100
+	defaultBatchMaxWait   = 100 * time.Millisecond
+)
+
+type batchOptions struct {
+	maxEvents int
+	maxWait   time.Duration
+}
+
+// batcher groups the events dispatched to the handlers, a batch is dispatched once it holds
+// maxEvents events, once maxWait elapsed since its first event, or once the stream is closed.
+type batcher struct {
+	opts   *batchOptions
+	events []Event
+	timer  *time.Timer
+}
+
+func newBatcher(opts *batchOptions) *batcher {
+	if opts == nil {
+		return nil
+	}
+	timer := time.NewTimer(opts.maxWait)
+	timer.Stop()
+	return &batcher{
+		opts:   opts,
+		events: make([]Event, 0, opts.maxEvents),
+		timer:  timer,
+	}
+}
+
+// add appends the event to the batch, true once the batch is full.
+func (b *batcher) add(ev Event) bool {
+	if len(b.events) == 0 {
+		b.timer.Reset(b.opts.maxWait)
+	}
+	b.events = append(b.events, ev)
+	return len(b.events) >= b.opts.maxEvents
+}
+
+// C returns the channel receiving the maxWait timeouts, nil while the batch is empty.
+func (b *batcher) C() <-chan time.Time {
+	if b == nil || len(b.events) == 0 {
+		return nil
+	}
+	return b.timer.C
+}
+
+// take returns the events of the batch and starts a new one.
+func (b *batcher) take() []Event {
+	if b == nil || len(b.events) == 0 {
+		return nil
+	}
+	b.timer.Stop()
+	events := b.events
+	b.events = make([]Event, 0, b.opts.maxEvents)
+	return events
+}
+
+// dispatchBatch calls the event handlers with the array of the events of the batch.
+func (c *Client) dispatchBatch(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	values := make([]interface{}, len(events))
+	for i, ev := range events {
+		values[i] = ev
+	}
+	c.dispatch(c.rt.NewArray(values...), events...)
+}
+
+func parseBatchOptions(rt *sobek.Runtime, batchV sobek.Value) (*batchOptions, error) {
+	opts := &batchOptions{
+		maxEvents: defaultBatchMaxEvents,
+		maxWait:   defaultBatchMaxWait,
+	}
+
+	batchObj := batchV.ToObject(rt)
+	for _, k := range batchObj.Keys() {
+		v := batchObj.Get(k)
+		if sobek.IsUndefined(v) || sobek.IsNull(v) {
+			continue
+		}
+		switch k {
+		case "maxEvents":
+			opts.maxEvents = int(v.ToInteger())
+			if opts.maxEvents <= 0 {
+				return nil, errors.New("invalid maxEvents: must be positive")
+			}
+		case "maxWait":
+			d, err := time.ParseDuration(v.String())
+			if err != nil {
+				return nil, fmt.Errorf("invalid maxWait: %w", err)
+			}
+			if d <= 0 {
+				return nil, errors.New("invalid maxWait: must be positive")
+			}
+			opts.maxWait = d
+		}
+	}
+
+	return opts, nil
+}
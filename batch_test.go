@@ -0,0 +1,87 @@
+package sse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/metrics"
+)
+
+func TestBatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("max events", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-burst", sseBurstHandler(25))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var batches = [], data = []
+		sse.open("HTTPBIN_IP_URL/sse-burst", {batch: {maxEvents: 10, maxWait: "10s"}}, function(client){
+			client.on("event", function(events) {
+				if (!Array.isArray(events)) {
+					throw new Error("events are not batched")
+				}
+				batches.push(events.length)
+				events.forEach(function(event) { data.push(event.data) })
+			})
+		})
+		if (batches.join() != "10,10,5") {
+			throw new Error("unexpected batches: " + batches.join())
+		}
+		if (data[0] != "0" || data[24] != "24") {
+			throw new Error("unexpected events: " + data.join())
+		}
+		`))
+		require.NoError(t, err)
+
+		var events, handlerDurations, dispatchDelays int
+		for _, sampleContainer := range metrics.GetBufferedSamples(test.samples) {
+			for _, sample := range sampleContainer.GetSamples() {
+				switch sample.Metric.Name {
+				case MetricEventName:
+					events++
+				case MetricHandlerDurationName:
+					handlerDurations++
+				case MetricDispatchDelayName:
+					dispatchDelays++
+				}
+			}
+		}
+		assert.Equal(t, 25, events)
+		assert.Equal(t, 3, handlerDurations, "the handler duration is pushed per batch")
+		assert.Equal(t, 25, dispatchDelays, "the dispatch delay is pushed per event")
+	})
+
+	t.Run("max wait", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-flushed", sseFlushedHandler(t))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var batches = []
+		sse.open("HTTPBIN_IP_URL/sse-flushed", {batch: {maxEvents: 100, maxWait: "5ms"}}, function(client){
+			client.on("event", function(events) { batches.push(events.length) })
+		})
+		if (batches.join() != "1,1,1,1,1") {
+			throw new Error("unexpected batches: " + batches.join())
+		}
+		`))
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		_, err := test.VU.Runtime().RunString(sr(`sse.open("HTTPBIN_IP_URL/sse", {batch: {maxEvents: 0}}, function(client){});`))
+		require.ErrorContains(t, err, "invalid sse.open() batch: invalid maxEvents: must be positive")
+
+		_, err = test.VU.Runtime().RunString(sr(`sse.open("HTTPBIN_IP_URL/sse", {batch: {maxWait: "0s"}}, function(client){});`))
+		require.ErrorContains(t, err, "invalid sse.open() batch: invalid maxWait: must be positive")
+	})
+}
@@ -0,0 +1,203 @@
+package sse
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/grafana/sobek"
+	"go.k6.io/k6/js/common"
+	httpModule "go.k6.io/k6/js/modules/k6/http"
+)
+
+// errFSFileBody is raised for k6/experimental/fs files, k6 exposes no public api to read them synchronously.
+var errFSFileBody = errors.New("k6/experimental/fs files are not supported, read the file with open(path, 'b') instead")
+
+// errOneShotBody is raised when an iterator body would be sent again on reconnect or failover.
+var errOneShotBody = errors.New("an iterator is sent only once, use a generator function to reconnect or fail over")
+
+// requestBody is the body of the sse request. It is either static data, or an iterator of chunks
+// streamed while the request is sent. The iterator of a generator is created again for each connection.
+type requestBody struct {
+	data        []byte
+	generator   sobek.Callable
+	iterator    *sobek.Object
+	contentType string
+}
+
+// reader returns the reader of the body and its length, -1 if unknown.
+func (b *requestBody) reader() (io.Reader, int64, error) {
+	switch {
+	case b == nil:
+		return http.NoBody, 0, nil
+	case b.isStreamed():
+		return nil, -1, nil
+	default:
+		return bytes.NewReader(b.data), int64(len(b.data)), nil
+	}
+}
+
+// isStreamed returns true if the body chunks are produced by a JS iterator.
+func (b *requestBody) isStreamed() bool {
+	return b != nil && (b.generator != nil || b.iterator != nil)
+}
+
+// isOneShot returns true if the body is an iterator which cannot be created again.
+func (b *requestBody) isOneShot() bool {
+	return b != nil && b.generator == nil && b.iterator != nil
+}
+
+// nextIterator returns the iterator of the connection. The iterator created when the params
+// were parsed is used by the first connection, the generator is called again for the next ones.
+func (b *requestBody) nextIterator(rt *sobek.Runtime) (*sobek.Object, error) {
+	it := b.iterator
+	if b.generator == nil {
+		return it, nil
+	}
+	b.iterator = nil
+	if it == nil {
+		v, err := b.generator(sobek.Undefined())
+		if err != nil {
+			return nil, err
+		}
+		it = v.ToObject(rt)
+	}
+	return it, nil
+}
+
+// stream writes the chunks produced by the iterator to the writer. It must run on the JS thread.
+func (b *requestBody) stream(rt *sobek.Runtime, w io.Writer) error {
+	it, err := b.nextIterator(rt)
+	if err != nil {
+		return err
+	}
+	if it == nil {
+		return errOneShotBody
+	}
+	next, ok := sobek.AssertFunction(it.Get("next"))
+	if !ok {
+		return errors.New("body iterator has no next function")
+	}
+
+	for {
+		res, err := next(it)
+		if err != nil {
+			return err
+		}
+		resObj := res.ToObject(rt)
+		if resObj.Get("done").ToBoolean() {
+			return nil
+		}
+
+		chunk, err := valueToBytes(resObj.Get("value"))
+		if err != nil {
+			return fmt.Errorf("invalid body chunk: %w", err)
+		}
+		if _, err = w.Write(chunk); err != nil {
+			return err
+		}
+	}
+}
+
+// do sends the request. A streamed body is pumped from the JS thread
+// while the request is sent by the http client.
+func (c *Client) do(req *http.Request, body *requestBody) (*http.Response, error) {
+	if !body.isStreamed() {
+		return c.httpClient.Do(req)
+	}
+
+	pr, pw := io.Pipe()
+	req.Body = pr
+	req.ContentLength = -1
+
+	type doResult struct {
+		resp *http.Response
+		err  error
+	}
+	doChan := make(chan doResult, 1)
+	go func() {
+		resp, err := c.httpClient.Do(req) //nolint:bodyclose // Body is deferred closed in closeResponseBody
+		doChan <- doResult{resp: resp, err: err}
+	}()
+
+	// Writes fail once the transport closed the request body
+	_ = pw.CloseWithError(body.stream(c.rt, pw))
+
+	res := <-doChan
+	return res.resp, res.err
+}
+
+func parseBody(rt *sobek.Runtime, bodyV sobek.Value) (*requestBody, error) {
+	if data, err := valueToBytes(bodyV); err == nil {
+		return &requestBody{data: data}, nil
+	}
+
+	switch v := bodyV.Export().(type) {
+	case *httpModule.FileData:
+		data, err := common.ToBytes(v.Data)
+		if err != nil {
+			return nil, err
+		}
+		return &requestBody{data: data, contentType: v.ContentType}, nil
+	}
+	if isFSFile(bodyV) {
+		return nil, errFSFileBody
+	}
+
+	// Generator functions and iterators are streamed
+	var generator sobek.Callable
+	if fn, ok := sobek.AssertFunction(bodyV); ok {
+		it, err := fn(sobek.Undefined())
+		if err != nil {
+			return nil, err
+		}
+		generator, bodyV = fn, it
+	}
+	bodyObj := bodyV.ToObject(rt)
+	if _, ok := sobek.AssertFunction(bodyObj.Get("next")); ok {
+		return &requestBody{generator: generator, iterator: bodyObj}, nil
+	}
+
+	return parseJSONBody(bodyV)
+}
+
+// valueToBytes returns the bytes of strings, ArrayBuffers, typed arrays and DataViews.
+func valueToBytes(v sobek.Value) ([]byte, error) {
+	switch data := v.Export().(type) {
+	case string:
+		return []byte(data), nil
+	case sobek.ArrayBuffer:
+		return data.Bytes(), nil
+	}
+
+	if obj, ok := v.(*sobek.Object); ok && obj.Get("buffer") != nil {
+		if buffer, ok := obj.Get("buffer").Export().(sobek.ArrayBuffer); ok {
+			offset := obj.Get("byteOffset").ToInteger()
+			length := obj.Get("byteLength").ToInteger()
+			return buffer.Bytes()[offset : offset+length], nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid type %s, expected string, ArrayBuffer or typed array", v.ExportType())
+}
+
+// isFSFile returns true if the value is a k6/experimental/fs File, recognized by its public methods.
+func isFSFile(v sobek.Value) bool {
+	obj, ok := v.(*sobek.Object)
+	if !ok {
+		return false
+	}
+	for _, method := range []string{"read", "seek", "stat"} {
+		if _, ok := sobek.AssertFunction(obj.Get(method)); !ok {
+			return false
+		}
+	}
+	path := obj.Get("path")
+	if path == nil {
+		return false
+	}
+	_, ok = path.Export().(string)
+	return ok
+}
@@ -0,0 +1,176 @@
+package sse
+
+import (
+	"encoding/hex"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	httpModule "go.k6.io/k6/js/modules/k6/http"
+)
+
+// sseEchoBodyHandler echoes the request body as hex along with its content type and length.
+func sseEchoBodyHandler(t testing.TB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			// The client aborted the upload
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Echo-Content-Type", req.Header.Get("Content-Type"))
+		if len(req.TransferEncoding) > 0 {
+			w.Header().Set("Echo-Transfer-Encoding", req.TransferEncoding[0])
+		}
+		_, err = w.Write([]byte("data: " + hex.EncodeToString(body) + "\n\n"))
+		require.NoError(t, err)
+	})
+}
+
+func TestBody(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		body     string
+		expected []byte
+		headers  string
+	}{
+		{
+			name:     "whitespaces are kept",
+			body:     `"  hello\n"`,
+			expected: []byte("  hello\n"),
+		},
+		{
+			name:     "array buffer",
+			body:     `new Uint8Array([0, 1, 2, 255]).buffer`,
+			expected: []byte{0, 1, 2, 255},
+		},
+		{
+			name:     "typed array view",
+			body:     `new Uint8Array([0, 1, 2, 255]).subarray(1, 3)`,
+			expected: []byte{1, 2},
+		},
+		{
+			name:     "object",
+			body:     `{"ping": true}`,
+			expected: []byte(`{"ping":true}`),
+			headers:  "application/json",
+		},
+		{
+			name:     "http file",
+			body:     `http.file("audio", "audio.wav", "audio/wav")`,
+			expected: []byte("audio"),
+			headers:  "audio/wav",
+		},
+		{
+			name:     "generator",
+			body:     `function* () { yield "chunk1,"; yield new Uint8Array([50]); }`,
+			expected: []byte("chunk1,2"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			test := newTestState(t)
+			sr := test.tb.Replacer.Replace
+			test.tb.Mux.Handle("/sse-echo-body", sseEchoBodyHandler(t))
+
+			rt := test.VU.Runtime()
+			require.NoError(t, rt.Set("http", httpModule.New().NewModuleInstance(test.VU).Exports().Default))
+			require.NoError(t, rt.Set("expected", hex.EncodeToString(tc.expected)))
+			require.NoError(t, rt.Set("contentType", tc.headers))
+
+			_, err := rt.RunString(sr(`
+			var data = null;
+			var res = sse.open("HTTPBIN_IP_URL/sse-echo-body", {method: "POST", body: ` + tc.body + `}, function(client){
+				client.on("event", function(event) {
+					data = event.data;
+				});
+			});
+			if (data !== expected) {
+				throw new Error("unexpected body: " + data);
+			}
+			if (res.headers["Echo-Content-Type"] !== contentType) {
+				throw new Error("unexpected content type: " + res.headers["Echo-Content-Type"]);
+			}
+			`))
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("streamed generator is chunked", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-echo-body", sseEchoBodyHandler(t))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var res = sse.open("HTTPBIN_IP_URL/sse-echo-body", {method: "POST", body: function* () { yield "a"; }}, function(client){});
+		if (res.headers["Echo-Transfer-Encoding"] !== "chunked") {
+			throw new Error("unexpected transfer encoding: " + res.headers["Echo-Transfer-Encoding"]);
+		}
+		`))
+		require.NoError(t, err)
+	})
+
+	t.Run("generator error", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-echo-body", sseEchoBodyHandler(t))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		sse.open("HTTPBIN_IP_URL/sse-echo-body", {method: "POST", body: function* () { yield "a"; throw new Error("no more audio"); }}, function(client){});
+		`))
+		require.ErrorContains(t, err, "no more audio")
+	})
+
+	t.Run("generator sent on each connection", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-echo-body", sseEchoBodyHandler(t))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var bodies = []
+		sse.open("HTTPBIN_IP_URL/sse-echo-body", {
+			method: "POST",
+			body: function* () { yield "a"; yield "b"; },
+			reconnect: {delay: "10ms"},
+		}, function(client){
+			client.on("event", function(event) {
+				bodies.push(event.data)
+				if (bodies.length == 2) {
+					client.close()
+				}
+			})
+		})
+		if (bodies.join() != "6162,6162") {
+			throw new Error("unexpected bodies: " + bodies.join())
+		}
+		`))
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid bodies", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var it = (function* () { yield "a"; })()
+		sse.open("HTTPBIN_IP_URL/sse", {method: "POST", body: it, reconnect: {}}, function(client){});
+		`))
+		require.ErrorContains(t, err, "invalid sse.open() body: an iterator is sent only once")
+
+		_, err = test.VU.Runtime().RunString(sr(`
+		var file = {path: "audio.wav", read: function() {}, seek: function() {}, stat: function() {}}
+		sse.open("HTTPBIN_IP_URL/sse", {method: "POST", body: file}, function(client){});
+		`))
+		require.ErrorContains(t, err, "k6/experimental/fs files are not supported")
+	})
+}
@@ -0,0 +1,65 @@
+package sse
+
+import (
+	"time"
+
+	"go.k6.io/k6/metrics"
+)
+
+// Reasons of a stream close.
+const (
+	closeReasonServer    = "server"
+	closeReasonClient    = "client"
+	closeReasonContext   = "context"
+	closeReasonError     = "error"
+	closeReasonHeartbeat = "heartbeat"
+	closeReasonTimeout   = "timeout"
+	closeReasonThrottled = "throttled"
+)
+
+// CloseEvent is passed to the close handlers once the stream is closed.
+type CloseEvent struct {
+	Reason         string  `json:"reason"`
+	ByServer       bool    `json:"byServer" js:"byServer"`
+	EventsReceived int64   `json:"eventsReceived" js:"eventsReceived"`
+	Duration       float64 `json:"duration"`
+}
+
+// closeWith closes the stream recording the reason, only the first reason is kept.
+func (c *Client) closeWith(reason string) error {
+	if c.closeReason == "" {
+		c.closeReason = reason
+	}
+	return c.closeResponseBody()
+}
+
+// onReadClose closes the stream once the reader stopped. A nil error means the server ended the stream.
+// Read errors caused by a close on the client side are not forwarded.
+func (c *Client) onReadClose(err error) {
+	if c.closeReason != "" {
+		return
+	}
+	if err == nil {
+		_ = c.closeWith(closeReasonServer)
+		return
+	}
+	c.handleEvent("error", c.rt.ToValue(err))
+	_ = c.closeWith(closeReasonError)
+}
+
+func (c *Client) closeEvent() *CloseEvent {
+	return &CloseEvent{
+		Reason:         c.closeReason,
+		ByServer:       c.closeReason == closeReasonServer,
+		EventsReceived: c.eventsReceived,
+		Duration:       metrics.D(time.Since(c.openedAt)),
+	}
+}
+
+// closeReasonTags returns the tags with the close reason if the stream was closed.
+func (c *Client) closeReasonTags() *metrics.TagSet {
+	if c.closeReason == "" {
+		return c.tagsAndMeta.Tags
+	}
+	return c.tagsAndMeta.Tags.With("close_reason", c.closeReason)
+}
@@ -0,0 +1,110 @@
+package sse
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"github.com/andybalholm/brotli"
+	"github.com/grafana/sobek"
+	"github.com/klauspost/compress/zstd"
+	"go.k6.io/k6/lib/netext/httpext"
+)
+
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	reader io.Reader
+	count  *atomic.Int64
+}
+
+func (r countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count.Add(int64(n))
+	return n, err
+}
+
+// decodeBody returns the reader of the body decoded according to its content encodings,
+// applied in the reverse order they are listed. Closers of the decoders are returned
+// to be closed once the stream is read. The body is passed through from the first
+// unknown encoding, as it cannot be decoded further.
+func decodeBody(body io.Reader, contentEncoding string) (io.Reader, []io.Closer, error) {
+	if contentEncoding == "" {
+		return body, nil, nil
+	}
+
+	var closers []io.Closer
+	encodings := strings.Split(contentEncoding, ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		encoding := strings.ToLower(strings.TrimSpace(encodings[i]))
+		if encoding == "" || encoding == "identity" {
+			continue
+		}
+		compression, err := httpext.CompressionTypeString(encoding)
+		if err != nil {
+			return body, closers, nil
+		}
+
+		switch compression {
+		case httpext.CompressionTypeGzip:
+			decoder, err := gzip.NewReader(body)
+			if err != nil {
+				return nil, closers, err
+			}
+			closers = append(closers, decoder)
+			body = decoder
+		case httpext.CompressionTypeDeflate:
+			decoder, err := zlib.NewReader(body)
+			if err != nil {
+				return nil, closers, err
+			}
+			closers = append(closers, decoder)
+			body = decoder
+		case httpext.CompressionTypeZstd:
+			decoder, err := zstd.NewReader(body)
+			if err != nil {
+				return nil, closers, err
+			}
+			readCloser := decoder.IOReadCloser()
+			closers = append(closers, readCloser)
+			body = readCloser
+		case httpext.CompressionTypeBr:
+			body = brotli.NewReader(body)
+		}
+	}
+
+	return body, closers, nil
+}
+
+// parseCompression returns the Accept-Encoding header value of the encodings,
+// either a comma separated string or an array of gzip, deflate, br or zstd.
+func parseCompression(rt *sobek.Runtime, compressionV sobek.Value) (string, error) {
+	var encodings []string
+	if _, ok := compressionV.Export().([]any); ok {
+		if err := rt.ExportTo(compressionV, &encodings); err != nil {
+			return "", err
+		}
+	} else {
+		encodings = strings.Split(compressionV.String(), ",")
+	}
+
+	accepted := make([]string, 0, len(encodings))
+	for _, encoding := range encodings {
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		if encoding == "" {
+			continue
+		}
+		if _, err := httpext.CompressionTypeString(encoding); err != nil {
+			return "", fmt.Errorf("unknown compression %q", encoding)
+		}
+		accepted = append(accepted, encoding)
+	}
+	if len(accepted) == 0 {
+		return "", errors.New("no compression set")
+	}
+
+	return strings.Join(accepted, ", "), nil
+}
@@ -0,0 +1,170 @@
+package sse
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/metrics"
+)
+
+// sseCompressedHandler streams events compressed with the first encoding accepted by the client.
+func sseCompressedHandler(t testing.TB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		encoding := strings.TrimSpace(strings.Split(req.Header.Get("Accept-Encoding"), ",")[0])
+
+		var encoder io.WriteCloser
+		switch encoding {
+		case "gzip":
+			encoder = gzip.NewWriter(w)
+		case "deflate":
+			encoder = zlib.NewWriter(w)
+		case "br":
+			encoder = brotli.NewWriter(w)
+		case "zstd":
+			var err error
+			encoder, err = zstd.NewWriter(w)
+			require.NoError(t, err)
+		default:
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		w.Header().Set("Content-Encoding", encoding)
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		for i := 0; i < 3; i++ {
+			_, err := encoder.Write([]byte("data: " + strings.Repeat("k6", 100) + "\n\n"))
+			require.NoError(t, err)
+		}
+		require.NoError(t, encoder.Close())
+	})
+}
+
+func TestCompression(t *testing.T) {
+	t.Parallel()
+
+	for _, compression := range []string{"gzip", "deflate", "br", "zstd"} {
+		t.Run(compression, func(t *testing.T) {
+			t.Parallel()
+			test := newTestState(t)
+			sr := test.tb.Replacer.Replace
+			test.tb.Mux.Handle("/sse-compressed", sseCompressedHandler(t))
+
+			rt := test.VU.Runtime()
+			require.NoError(t, rt.Set("compression", compression))
+			_, err := rt.RunString(sr(`
+			var events = [];
+			var res = sse.open("HTTPBIN_IP_URL/sse-compressed", {compression: [compression, "gzip"]}, function(client){
+				client.on("event", function(event) {
+					events.push(event.data);
+				});
+			});
+			if (res.status !== 200 || res.headers["Content-Encoding"] !== compression) {
+				throw new Error("unexpected response: " + res.status + " " + res.headers["Content-Encoding"]);
+			}
+			if (events.length !== 3 || events[0] !== "k6".repeat(100)) {
+				throw new Error("unexpected events: " + events);
+			}
+			`))
+			require.NoError(t, err)
+
+			var wireBytes, decodedBytes float64
+			for _, sampleContainer := range metrics.GetBufferedSamples(test.samples) {
+				for _, sample := range sampleContainer.GetSamples() {
+					switch sample.Metric.Name {
+					case MetricWireBytesName:
+						wireBytes += sample.Value
+					case MetricDecodedBytesName:
+						decodedBytes += sample.Value
+					}
+				}
+			}
+			assert.Equal(t, float64(3*len("data: \n\n")+3*200), decodedBytes)
+			assert.Positive(t, wireBytes)
+			assert.Less(t, wireBytes, decodedBytes)
+		})
+	}
+
+	t.Run("uncompressed", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var res = sse.open("HTTPBIN_IP_URL/sse", function(client){});
+		`))
+		require.NoError(t, err)
+
+		samples := map[string]float64{}
+		for _, sampleContainer := range metrics.GetBufferedSamples(test.samples) {
+			for _, sample := range sampleContainer.GetSamples() {
+				samples[sample.Metric.Name] += sample.Value
+			}
+		}
+		assert.Positive(t, samples[MetricWireBytesName])
+		assert.Equal(t, samples[MetricWireBytesName], samples[MetricDecodedBytesName])
+	})
+
+	t.Run("not requested", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-mislabeled", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Encoding", "br")
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: k6\n\n"))
+		}))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var events = [], errors = [];
+		sse.open("HTTPBIN_IP_URL/sse-mislabeled", function(client){
+			client.on("event", function(event) { events.push(event.data) });
+			client.on("error", function(e) { errors.push(e.error()) });
+		});
+		if (events.join() !== "k6" || errors.length !== 0) {
+			throw new Error("unexpected events: " + events + " " + errors);
+		}
+		`))
+		require.NoError(t, err)
+	})
+
+	t.Run("unknown content encoding", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-custom-encoding", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Encoding", "x-custom")
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: k6\n\n"))
+		}))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var events = [];
+		sse.open("HTTPBIN_IP_URL/sse-custom-encoding", {compression: "gzip"}, function(client){
+			client.on("event", function(event) { events.push(event.data) });
+		});
+		if (events.join() !== "k6") {
+			throw new Error("unexpected events: " + events);
+		}
+		`))
+		require.NoError(t, err)
+	})
+
+	t.Run("unknown compression", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		sse.open("HTTPBIN_IP_URL/sse", {compression: "gzip, lzw"}, function(client){});
+		`))
+		require.ErrorContains(t, err, `invalid sse.open() compression: unknown compression "lzw"`)
+	})
+}
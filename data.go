@@ -0,0 +1,96 @@
+package sse
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/netext"
+	"go.k6.io/k6/metrics"
+)
+
+// defaultFlushInterval is the interval the in-flight metrics of a stream are pushed at,
+// the bytes sent and received, the number of active streams and the age of the stream.
+const defaultFlushInterval = time.Second
+
+// countingConn counts the bytes read and written on the connection of a stream.
+type countingConn struct {
+	net.Conn
+	read, written *atomic.Int64
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.read.Add(int64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.written.Add(int64(n))
+	return n, err
+}
+
+// dialContext dials with the VU dialer and counts the bytes of the stream connections.
+func (c *Client) dialContext(dialer lib.DialContexter) func(context.Context, string, string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &countingConn{Conn: conn, read: &c.bytesRead, written: &c.bytesWritten}, nil
+	}
+}
+
+// pushDataMetrics pushes the bytes sent and received since the last push. The k6 dialer
+// counters are decreased accordingly, so the bytes are not pushed again at the end of the iteration:
+// k6 only swaps them once the iteration ends, after the stream is closed.
+func (c *Client) pushDataMetrics(t time.Time) {
+	read, written := c.bytesRead.Load(), c.bytesWritten.Load()
+	readDelta, writtenDelta := read-c.bytesReadPushed, written-c.bytesWrittenPushed
+	if readDelta == 0 && writtenDelta == 0 {
+		return
+	}
+	c.bytesReadPushed, c.bytesWrittenPushed = read, written
+
+	if dialer, ok := c.dialer.(*netext.Dialer); ok {
+		atomic.AddInt64(&dialer.BytesRead, -readDelta)
+		atomic.AddInt64(&dialer.BytesWritten, -writtenDelta)
+	}
+
+	metrics.PushIfNotDone(c.ctx, c.samplesOutput, metrics.ConnectedSamples{
+		Samples: []metrics.Sample{
+			{
+				TimeSeries: metrics.TimeSeries{
+					Metric: c.builtinMetrics.DataSent,
+					Tags:   c.tagsAndMeta.Tags,
+				},
+				Time:     t,
+				Metadata: c.tagsAndMeta.Metadata,
+				Value:    float64(writtenDelta),
+			},
+			{
+				TimeSeries: metrics.TimeSeries{
+					Metric: c.builtinMetrics.DataReceived,
+					Tags:   c.tagsAndMeta.Tags,
+				},
+				Time:     t,
+				Metadata: c.tagsAndMeta.Metadata,
+				Value:    float64(readDelta),
+			},
+			{
+				TimeSeries: metrics.TimeSeries{
+					Metric: c.sseMetrics.SSEBytesReceived,
+					Tags:   c.tagsAndMeta.Tags,
+				},
+				Time:     t,
+				Metadata: c.tagsAndMeta.Metadata,
+				Value:    float64(readDelta),
+			},
+		},
+		Tags: c.tagsAndMeta.Tags,
+		Time: t,
+	})
+}
@@ -0,0 +1,81 @@
+package sse
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/lib/netext"
+	"go.k6.io/k6/metrics"
+)
+
+// sseFlushedHandler flushes an event every 30ms.
+func sseFlushedHandler(t testing.TB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		for i := 0; i < 5; i++ {
+			_, err := w.Write([]byte("data: flushed\n\n"))
+			require.NoError(t, err)
+			w.(http.Flusher).Flush()
+			time.Sleep(30 * time.Millisecond)
+		}
+	})
+}
+
+func TestDataMetrics(t *testing.T) {
+	t.Parallel()
+
+	t.Run("pushed during the stream", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-flushed", sseFlushedHandler(t))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		sse.open("HTTPBIN_IP_URL/sse-flushed", {flushInterval: "20ms"}, function(client){});
+		`))
+		require.NoError(t, err)
+
+		var dataReceived, dataSent, bytesReceived float64
+		dataReceivedSamples := 0
+		for _, sampleContainer := range metrics.GetBufferedSamples(test.samples) {
+			for _, sample := range sampleContainer.GetSamples() {
+				switch sample.Metric.Name {
+				case metrics.DataReceivedName:
+					dataReceived += sample.Value
+					dataReceivedSamples++
+					url, _ := sample.Tags.Get(metrics.TagURL.String())
+					assert.Equal(t, sr("HTTPBIN_IP_URL/sse-flushed"), url)
+				case metrics.DataSentName:
+					dataSent += sample.Value
+				case MetricBytesReceivedName:
+					bytesReceived += sample.Value
+				}
+			}
+		}
+
+		assert.Greater(t, dataReceivedSamples, 1, "data_received is pushed periodically")
+		assert.Positive(t, dataSent)
+		assert.Greater(t, dataReceived, float64(5*len("data: flushed\n\n")))
+		assert.Equal(t, dataReceived, bytesReceived)
+
+		// The bytes pushed by the stream are not pushed again by the dialer at the end of the iteration
+		dialer, ok := test.tb.Dialer.(*netext.Dialer)
+		require.True(t, ok)
+		assert.Zero(t, atomic.LoadInt64(&dialer.BytesRead))
+		assert.Zero(t, atomic.LoadInt64(&dialer.BytesWritten))
+	})
+
+	t.Run("invalid flush interval", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		sse.open("HTTPBIN_IP_URL/sse", {flushInterval: "0s"}, function(client){});
+		`))
+		require.ErrorContains(t, err, "invalid sse.open() flushInterval: must be positive")
+	})
+}
@@ -0,0 +1,70 @@
+package sse
+
+import (
+	"time"
+
+	"github.com/grafana/sobek"
+	"go.k6.io/k6/metrics"
+)
+
+// handlerDominanceRatio is the share of the stream duration spent in the event handlers above which
+// a warning is logged, the stream was then read at the pace of the script rather than of the server.
+const handlerDominanceRatio = 0.5
+
+// dispatchEvent calls the event handlers and pushes the time the event waited before
+// the handlers started, since it was parsed, and the time spent in the handlers.
+func (c *Client) dispatchEvent(ev Event) {
+	c.dispatch(c.rt.ToValue(ev), ev)
+}
+
+// dispatch calls the event handlers with the value of the events and pushes their dispatch metrics.
+func (c *Client) dispatch(value sobek.Value, events ...Event) {
+	start := time.Now()
+	c.handleEvent("event", value)
+	end := time.Now()
+	c.handlerTime += end.Sub(start)
+
+	samples := []metrics.Sample{
+		{
+			TimeSeries: metrics.TimeSeries{
+				Metric: c.sseMetrics.SSEHandlerDuration,
+				Tags:   c.tagsAndMeta.Tags,
+			},
+			Time:     end,
+			Metadata: c.tagsAndMeta.Metadata,
+			Value:    metrics.D(end.Sub(start)),
+		},
+	}
+	for _, ev := range events {
+		if ev.parsed.IsZero() {
+			continue
+		}
+		samples = append(samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: c.sseMetrics.SSEDispatchDelay,
+				Tags:   c.tagsAndMeta.Tags,
+			},
+			Time:     start,
+			Metadata: c.tagsAndMeta.Metadata,
+			Value:    metrics.D(start.Sub(ev.parsed)),
+		})
+	}
+
+	metrics.PushIfNotDone(c.ctx, c.samplesOutput, metrics.ConnectedSamples{
+		Samples: samples,
+		Tags:    c.tagsAndMeta.Tags,
+		Time:    end,
+	})
+}
+
+// warnSlowHandlers logs a warning if the event handlers took most of the stream duration.
+func (c *Client) warnSlowHandlers() {
+	elapsed := time.Since(c.openedAt)
+	if c.state.Logger == nil || c.handlerTime <= time.Duration(handlerDominanceRatio*float64(elapsed)) {
+		return
+	}
+	c.state.Logger.WithField("url", c.url).Warnf(
+		"sse event handlers took %s of the %s the stream was open, the events were read at the pace of the script, "+
+			"see sse_dispatch_delay",
+		c.handlerTime.Round(time.Millisecond), elapsed.Round(time.Millisecond))
+}
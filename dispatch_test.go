@@ -0,0 +1,76 @@
+package sse
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/metrics"
+)
+
+func TestDispatchMetrics(t *testing.T) {
+	t.Parallel()
+
+	t.Run("slow handlers", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		// The events are sent at once, so they wait for the previous handlers however the goroutines are scheduled
+		test.tb.Mux.Handle("/sse-burst", sseBurstHandler(5))
+		logger, hook := logtest.NewNullLogger()
+		test.VU.StateField.Logger = logger
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		sse.open("HTTPBIN_IP_URL/sse-burst", function(client){
+			client.on("event", function() {
+				var start = Date.now()
+				while (Date.now() - start < 50) {}
+			})
+		});
+		`))
+		require.NoError(t, err)
+
+		var handlerDurations, dispatchDelays []float64
+		for _, sampleContainer := range metrics.GetBufferedSamples(test.samples) {
+			for _, sample := range sampleContainer.GetSamples() {
+				switch sample.Metric.Name {
+				case MetricHandlerDurationName:
+					handlerDurations = append(handlerDurations, sample.Value)
+				case MetricDispatchDelayName:
+					dispatchDelays = append(dispatchDelays, sample.Value)
+				}
+			}
+		}
+
+		require.Len(t, handlerDurations, 5)
+		for _, duration := range handlerDurations {
+			assert.GreaterOrEqual(t, duration, float64(45))
+		}
+		require.Len(t, dispatchDelays, 5)
+		assert.Greater(t, dispatchDelays[4], float64(10), "the last event waited for the previous handlers")
+
+		entries := hook.AllEntries()
+		require.Len(t, entries, 1)
+		assert.Equal(t, logrus.WarnLevel, entries[0].Level)
+		assert.Contains(t, entries[0].Message, "sse event handlers took")
+	})
+
+	t.Run("fast handlers", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-flushed", sseFlushedHandler(t))
+		logger, hook := logtest.NewNullLogger()
+		test.VU.StateField.Logger = logger
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		sse.open("HTTPBIN_IP_URL/sse-flushed", function(client){
+			client.on("event", function() {})
+		});
+		`))
+		require.NoError(t, err)
+		assert.Empty(t, hook.AllEntries())
+	})
+}
@@ -100,7 +100,7 @@ export default function () {
         "stop": ["<|im_end|>"] // Fix for not instructed models
     }
 
-    const params = {method: 'POST', body: JSON.stringify(payload)};
+    const params = {method: 'POST', json: payload};
 
     const startTime = new Date()
     let promptEvalEndTime = null
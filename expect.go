@@ -0,0 +1,408 @@
+package sse
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/sobek"
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/metrics"
+)
+
+// defaultExpectCheckName is the check tag of the sample pushed by sse.expectStream without name option.
+const defaultExpectCheckName = "stream matches golden events"
+
+// goldenDataPath is the path of the data parsed as JSON, prefixing the paths of its values in masks and tolerances.
+const goldenDataPath = "data.$"
+
+// StreamExpectation is the result of sse.expectStream.
+type StreamExpectation struct {
+	Pass bool `json:"pass"`
+	// Diff lists the differences with the golden events, one per line
+	Diff string `json:"diff"`
+	// Received holds the events received, to record the golden events
+	Received []Event       `json:"received"`
+	Response *HTTPResponse `json:"response"`
+	Error    string        `json:"error"`
+}
+
+type expectOptions struct {
+	// ignore and tolerance are keyed by paths like data.$.items.*.price, * matching any key or index
+	ignore    []string
+	tolerance map[string]float64
+	name      string
+}
+
+// ExpectStream opens the stream, receives as many events as the golden events, and compares them
+// field by field, except the ignored ones. A check is pushed with the result.
+func (mi *sse) ExpectStream(urlV, paramsV, goldenV, optionsV sobek.Value) (*StreamExpectation, error) {
+	rt := mi.vu.Runtime()
+	state := mi.vu.State()
+	if state == nil {
+		return nil, ErrSSEInInitContext
+	}
+
+	golden, err := parseGolden(goldenV)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sse.expectStream() golden events: %w", err)
+	}
+	opts := &expectOptions{name: defaultExpectCheckName}
+	if !common.IsNullish(optionsV) {
+		opts, err = parseExpectOptions(rt, optionsV)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sse.expectStream() options: %w", err)
+		}
+	}
+
+	result := &StreamExpectation{Received: []Event{}}
+
+	// The stream is closed once all the golden events are received
+	setupFn := rt.ToValue(func(call sobek.FunctionCall) sobek.Value {
+		client, ok := call.Argument(0).Export().(**Client)
+		if !ok {
+			return sobek.Undefined()
+		}
+		c := *client
+		if len(golden) == 0 {
+			_ = c.Close()
+		}
+		c.On("event", rt.ToValue(func(v sobek.Value) {
+			switch received := v.Export().(type) {
+			case Event:
+				result.Received = append(result.Received, received)
+			case []any:
+				for _, ev := range received {
+					if ev, ok := ev.(Event); ok {
+						result.Received = append(result.Received, ev)
+					}
+				}
+			}
+			if len(result.Received) >= len(golden) && !c.closed {
+				_ = c.Close()
+			}
+		}))
+		c.On("error", rt.ToValue(func(v sobek.Value) {
+			if err, ok := v.Export().(error); ok && result.Error == "" {
+				result.Error = err.Error()
+			}
+		}))
+		return sobek.Undefined()
+	})
+
+	args := []sobek.Value{setupFn}
+	if !common.IsNullish(paramsV) {
+		args = []sobek.Value{paramsV, setupFn}
+	}
+	result.Response, err = mi.Open(urlV, args...)
+	if err != nil {
+		return nil, err
+	}
+	if result.Response.Error != "" {
+		result.Error = result.Response.Error
+	}
+
+	diffs := opts.compare(golden, result.Received)
+	result.Diff = strings.Join(diffs, "\n")
+	result.Pass = len(diffs) == 0 && result.Error == ""
+
+	mi.pushCheck(opts.name, result.Pass)
+	return result, nil
+}
+
+// pushCheck pushes the checks sample of the expectation, as the k6 check function.
+func (mi *sse) pushCheck(name string, pass bool) {
+	state := mi.vu.State()
+	tagsAndMeta := state.Tags.GetCurrentValues()
+	tags := tagsAndMeta.Tags
+	if state.Options.SystemTags.Has(metrics.TagCheck) {
+		tags = tags.With("check", name)
+	}
+	sample := metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: state.BuiltinMetrics.Checks,
+			Tags:   tags,
+		},
+		Time:     time.Now(),
+		Metadata: tagsAndMeta.Metadata,
+	}
+	if pass {
+		sample.Value = 1
+	}
+	metrics.PushIfNotDone(mi.vu.Context(), state.Samples, sample)
+}
+
+// compare returns the differences between the golden and the received events.
+// The fields missing from a golden event are not compared.
+func (opts *expectOptions) compare(golden []map[string]any, received []Event) []string {
+	var diffs []string
+	for i := 0; i < len(golden) || i < len(received); i++ {
+		prefix := fmt.Sprintf("event #%d", i+1)
+		switch {
+		case i >= len(received):
+			diffs = append(diffs, fmt.Sprintf("%s: missing, expected %s", prefix, formatValue(golden[i])))
+			continue
+		case i >= len(golden):
+			unexpected := map[string]any{"id": received[i].ID, "name": received[i].Name, "data": received[i].Data}
+			if received[i].Comment != "" {
+				unexpected["comment"] = received[i].Comment
+			}
+			diffs = append(diffs, fmt.Sprintf("%s: unexpected %s", prefix, formatValue(unexpected)))
+			continue
+		}
+
+		ev := received[i]
+		for _, field := range []string{"id", "comment", "name", "data"} {
+			expected, ok := golden[i][field]
+			if !ok || opts.ignored(field) {
+				continue
+			}
+			switch field {
+			case "id":
+				diffs = opts.compareValue(diffs, prefix, field, expected, ev.ID)
+			case "comment":
+				diffs = opts.compareValue(diffs, prefix, field, expected, ev.Comment)
+			case "name":
+				diffs = opts.compareValue(diffs, prefix, field, expected, ev.Name)
+			case "data":
+				diffs = opts.compareData(diffs, prefix, expected, ev.Data)
+			}
+		}
+	}
+	return diffs
+}
+
+// compareData compares the data as JSON values if the golden data is JSON, as strings otherwise.
+func (opts *expectOptions) compareData(diffs []string, prefix string, expected any, data string) []string {
+	if s, ok := expected.(string); ok {
+		if err := json.Unmarshal([]byte(s), &expected); err != nil {
+			return opts.compareValue(diffs, prefix, "data", s, data)
+		}
+	}
+	var actual any
+	if err := json.Unmarshal([]byte(data), &actual); err != nil {
+		return append(diffs, fmt.Sprintf("%s data: expected JSON %s, received %q", prefix, formatValue(expected), data))
+	}
+	return opts.compareValue(diffs, prefix, goldenDataPath, expected, actual)
+}
+
+// compareValue appends the differences between the expected and actual values at the path.
+func (opts *expectOptions) compareValue(diffs []string, prefix, path string, expected, actual any) []string {
+	if opts.ignored(path) {
+		return diffs
+	}
+	mismatch := func() []string {
+		return append(diffs, fmt.Sprintf("%s %s: expected %s, received %s", prefix, path, formatValue(expected), formatValue(actual)))
+	}
+
+	switch e := expected.(type) {
+	case map[string]any:
+		a, ok := actual.(map[string]any)
+		if !ok {
+			return mismatch()
+		}
+		keys := make([]string, 0, len(e)+len(a))
+		for k := range e {
+			keys = append(keys, k)
+		}
+		for k := range a {
+			if _, ok := e[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			diffs = opts.compareMember(diffs, prefix, path+"."+k, e, a, k)
+		}
+		return diffs
+	case []any:
+		a, ok := actual.([]any)
+		if !ok {
+			return mismatch()
+		}
+		for i := 0; i < len(e) || i < len(a); i++ {
+			elemPath := path + "." + strconv.Itoa(i)
+			switch {
+			case opts.ignored(elemPath):
+			case i >= len(a):
+				diffs = append(diffs, fmt.Sprintf("%s %s: missing, expected %s", prefix, elemPath, formatValue(e[i])))
+			case i >= len(e):
+				diffs = append(diffs, fmt.Sprintf("%s %s: unexpected %s", prefix, elemPath, formatValue(a[i])))
+			default:
+				diffs = opts.compareValue(diffs, prefix, elemPath, e[i], a[i])
+			}
+		}
+		return diffs
+	case float64:
+		a, ok := actual.(float64)
+		if !ok || math.Abs(e-a) > opts.toleranceOf(path) {
+			return mismatch()
+		}
+		return diffs
+	default:
+		if expected != actual {
+			return mismatch()
+		}
+		return diffs
+	}
+}
+
+// compareMember compares the key of the expected and actual objects.
+func (opts *expectOptions) compareMember(diffs []string, prefix, path string, expected, actual map[string]any, k string) []string {
+	e, inExpected := expected[k]
+	a, inActual := actual[k]
+	switch {
+	case opts.ignored(path):
+		return diffs
+	case !inActual:
+		return append(diffs, fmt.Sprintf("%s %s: missing, expected %s", prefix, path, formatValue(e)))
+	case !inExpected:
+		return append(diffs, fmt.Sprintf("%s %s: unexpected %s", prefix, path, formatValue(a)))
+	default:
+		return opts.compareValue(diffs, prefix, path, e, a)
+	}
+}
+
+func (opts *expectOptions) ignored(path string) bool {
+	for _, pattern := range opts.ignore {
+		if matchPath(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// toleranceOf returns the tolerance of the path, the one of its exact path taking precedence over the patterns.
+func (opts *expectOptions) toleranceOf(path string) float64 {
+	if tolerance, ok := opts.tolerance[path]; ok {
+		return tolerance
+	}
+	for pattern, tolerance := range opts.tolerance {
+		if matchPath(pattern, path) {
+			return tolerance
+		}
+	}
+	return 0
+}
+
+// matchPath reports whether the dotted path matches the pattern, * matching any key or index.
+func matchPath(pattern, path string) bool {
+	patternSegments, pathSegments := strings.Split(pattern, "."), strings.Split(path, ".")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		if segment != "*" && segment != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func formatValue(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// parseGolden parses the golden events, either a JSON array or ndjson as returned by open(), or an array of events.
+func parseGolden(goldenV sobek.Value) ([]map[string]any, error) {
+	if common.IsNullish(goldenV) {
+		return nil, errors.New("required")
+	}
+
+	var golden []map[string]any
+	switch v := goldenV.Export().(type) {
+	case string:
+		content := strings.TrimSpace(v)
+		if strings.HasPrefix(content, "[") {
+			if err := json.Unmarshal([]byte(content), &golden); err != nil {
+				return nil, err
+			}
+			break
+		}
+		scanner := bufio.NewScanner(strings.NewReader(content))
+		scanner.Buffer(nil, defaultMaxEventBytes)
+		for line := 1; scanner.Scan(); line++ {
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+			var ev map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			golden = append(golden, ev)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	default:
+		// The events are converted to JSON so their values are compared as the ones of a file
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &golden); err != nil {
+			return nil, errors.New("must be an array of events, or their JSON or ndjson content")
+		}
+	}
+
+	for i, ev := range golden {
+		if ev == nil {
+			return nil, fmt.Errorf("event #%d is not an object", i+1)
+		}
+		for field, value := range ev {
+			switch field {
+			case "id", "comment", "name":
+				if _, ok := value.(string); !ok {
+					return nil, fmt.Errorf("event #%d %s must be a string", i+1, field)
+				}
+			case "data":
+			default:
+				return nil, fmt.Errorf("event #%d has unknown field %q", i+1, field)
+			}
+		}
+	}
+	return golden, nil
+}
+
+func parseExpectOptions(rt *sobek.Runtime, optionsV sobek.Value) (*expectOptions, error) {
+	opts := &expectOptions{name: defaultExpectCheckName}
+
+	optionsObj := optionsV.ToObject(rt)
+	for _, k := range optionsObj.Keys() {
+		v := optionsObj.Get(k)
+		if sobek.IsUndefined(v) || sobek.IsNull(v) {
+			continue
+		}
+		switch k {
+		case "ignore":
+			if err := rt.ExportTo(v, &opts.ignore); err != nil {
+				return nil, fmt.Errorf("invalid ignore: %w", err)
+			}
+		case "tolerance":
+			if err := rt.ExportTo(v, &opts.tolerance); err != nil {
+				return nil, fmt.Errorf("invalid tolerance: %w", err)
+			}
+			for path, tolerance := range opts.tolerance {
+				if tolerance < 0 {
+					return nil, fmt.Errorf("invalid tolerance of %s: must be positive", path)
+				}
+			}
+		case "name":
+			opts.name = v.String()
+		default:
+			return nil, fmt.Errorf("unknown option %q", k)
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,155 @@
+package sse
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/metrics"
+)
+
+// sseContractHandler sends events with an increasing id and a timestamp, then keeps the stream open.
+func sseContractHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 1; i <= 3; i++ {
+			_, _ = fmt.Fprintf(w, "id: %d\nevent: price\ndata: {\"symbol\":\"K6\",\"price\":%d.2,\"timestamp\":%d}\n\n",
+				time.Now().UnixNano()+int64(i), 10*i, time.Now().UnixMilli())
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+}
+
+func TestExpectStream(t *testing.T) {
+	t.Parallel()
+
+	checks := func(test testState) map[string]float64 {
+		results := map[string]float64{}
+		for _, sampleContainer := range metrics.GetBufferedSamples(test.samples) {
+			for _, sample := range sampleContainer.GetSamples() {
+				if sample.Metric.Name == "checks" {
+					name, _ := sample.Tags.Get("check")
+					results[name] = sample.Value
+				}
+			}
+		}
+		return results
+	}
+
+	t.Run("match", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-contract", sseContractHandler())
+		test.VU.StateField.Options.SystemTags = metrics.NewSystemTagSet(metrics.TagCheck)
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var golden = '{"name": "price", "data": {"symbol": "K6", "price": 10, "timestamp": 0}}\n' +
+			'{"name": "price", "data": "{\\"symbol\\":\\"K6\\",\\"price\\":20,\\"timestamp\\":0}"}\n' +
+			'\n' +
+			'{"name": "price", "data": {"symbol": "K6", "price": 30, "timestamp": 0}}\n'
+		var result = sse.expectStream("HTTPBIN_IP_URL/sse-contract", null, golden, {
+			ignore: ["id", "data.$.timestamp"],
+			tolerance: {"data.$.price": 0.5},
+			name: "price stream",
+		})
+		if (!result.pass || result.diff != "" || result.error != "") {
+			throw new Error("unexpected result: " + JSON.stringify(result))
+		}
+		if (result.received.length != 3 || result.received[0].name != "price" || result.response.status != 200) {
+			throw new Error("unexpected received events: " + JSON.stringify(result))
+		}
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]float64{"price stream": 1}, checks(test))
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-contract", sseContractHandler())
+		test.VU.StateField.Options.SystemTags = metrics.NewSystemTagSet(metrics.TagCheck)
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var golden = [
+			{name: "price", data: {symbol: "K6", price: 10.2}},
+			{name: "quote", data: {symbol: "K6", price: 21, timestamp: 0}},
+			{name: "price", data: {symbol: "K6", price: 30.2, currency: "EUR", timestamp: 0}},
+			{name: "price", data: "done"},
+		]
+		var result = sse.expectStream("HTTPBIN_IP_URL/sse-contract", {timeout: "200ms"}, golden, {
+			ignore: ["data.$.timestamp"],
+		})
+		if (result.pass) {
+			throw new Error("unexpected result: " + JSON.stringify(result))
+		}
+		var expected = [
+			'event #2 name: expected "quote", received "price"',
+			'event #2 data.$.price: expected 21, received 20.2',
+			'event #3 data.$.currency: missing, expected "EUR"',
+			'event #4: missing, expected {"data":"done","name":"price"}',
+		]
+		if (result.diff != expected.join("\n")) {
+			throw new Error("unexpected diff:\n" + result.diff)
+		}
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]float64{defaultExpectCheckName: 0}, checks(test))
+	})
+
+	t.Run("comment", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-commented", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte(": v1\ndata: a\n\n: v2\ndata: b\n\n"))
+		}))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var result = sse.expectStream("HTTPBIN_IP_URL/sse-commented", null, [
+			{comment: "v1", data: "a"},
+			{comment: "v1", data: "b"},
+		])
+		if (result.diff != 'event #2 comment: expected "v1", received "v2"') {
+			throw new Error("unexpected diff:\n" + result.diff)
+		}
+		if (result.received[0].comment != "v1") {
+			throw new Error("unexpected received events: " + JSON.stringify(result.received))
+		}
+		`))
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		_, err := test.VU.Runtime().RunString(sr(`sse.expectStream("HTTPBIN_IP_URL/sse", null, '{"data": "x"}\n{"data"');`))
+		require.ErrorContains(t, err, "invalid sse.expectStream() golden events: line 2: unexpected end of JSON input")
+
+		_, err = test.VU.Runtime().RunString(sr(`sse.expectStream("HTTPBIN_IP_URL/sse", null, [{event: "x"}]);`))
+		require.ErrorContains(t, err, `invalid sse.expectStream() golden events: event #1 has unknown field "event"`)
+
+		_, err = test.VU.Runtime().RunString(sr(`sse.expectStream("HTTPBIN_IP_URL/sse", null, [], {tolerance: {"data.$.price": -1}});`))
+		require.ErrorContains(t, err, "invalid sse.expectStream() options: invalid tolerance of data.$.price: must be positive")
+
+		_, err = test.VU.Runtime().RunString(sr(`sse.expectStream("HTTPBIN_IP_URL/sse", null, [], {ignored: ["id"]});`))
+		require.ErrorContains(t, err, `invalid sse.expectStream() options: unknown option "ignored"`)
+	})
+}
+
+func TestMatchPath(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, matchPath("id", "id"))
+	assert.True(t, matchPath("data.$.items.*.ts", "data.$.items.3.ts"))
+	assert.False(t, matchPath("data.$.items.*.ts", "data.$.items.3"))
+	assert.False(t, matchPath("data.$.ts", "data.$.items.ts"))
+}
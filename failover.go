@@ -0,0 +1,156 @@
+package sse
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/sobek"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/netext/httpext"
+)
+
+// Strategies selecting the endpoint of each connection when sse.open() is given a list of urls.
+const (
+	failoverRoundRobin = "round-robin"
+	failoverRandom     = "random"
+	failoverSticky     = "sticky"
+)
+
+// endpointTagName is the tag set to the endpoint of the connection on failover.
+const endpointTagName = "endpoint"
+
+var errNoEndpoint = errors.New("sse.open() requires at least one url")
+
+// endpoints selects the url of each connection of a stream among a list of urls.
+type endpoints struct {
+	urls     []string
+	strategy string
+	current  int
+	failed   bool
+}
+
+func newEndpoints(urls []string, strategy string) *endpoints {
+	if strategy == "" {
+		strategy = failoverSticky
+	}
+	return &endpoints{urls: urls, strategy: strategy, current: -1}
+}
+
+// next returns the url of the next connection. Round-robin rotates on each connection,
+// random picks any endpoint but the one that failed and sticky keeps the endpoint until it fails.
+func (e *endpoints) next() string {
+	n := len(e.urls)
+	switch {
+	case e.current < 0 && e.strategy == failoverRandom:
+		e.current = rand.IntN(n) //nolint:gosec // endpoint selection does not need a secure random
+	case e.current < 0:
+		e.current = 0
+	case e.strategy == failoverRoundRobin:
+		e.current = (e.current + 1) % n
+	case e.strategy == failoverRandom && e.failed && n > 1:
+		e.current = (e.current + 1 + rand.IntN(n-1)) % n //nolint:gosec // see above
+	case e.strategy == failoverRandom:
+		e.current = rand.IntN(n) //nolint:gosec // see above
+	case e.failed:
+		e.current = (e.current + 1) % n
+	}
+	e.failed = false
+	return e.urls[e.current]
+}
+
+// fail marks the endpoint of the current connection as failed, either on a connection error or a disconnection.
+func (e *endpoints) fail() {
+	if e == nil {
+		return
+	}
+	e.failed = true
+}
+
+// connectFailover connects to the endpoint selected by the strategy, and fails over to the next ones
+// on a connection error or a non 2xx status, until as many endpoints as the list holds were tried.
+func (c *Client) connectFailover() (func(), error) {
+	if c.endpoints == nil {
+		return c.connectWithRetryAfter()
+	}
+
+	for tries := 1; ; tries++ {
+		c.requestURL = c.endpoints.next()
+		c.setEndpointTags()
+
+		connEndHook, err := c.connectWithRetryAfter()
+		if (err == nil && isSuccess(c.resp)) || tries >= len(c.endpoints.urls) {
+			return connEndHook, err
+		}
+
+		c.endpoints.fail()
+		if c.resp != nil {
+			_ = c.closeWith(closeReasonServer)
+		} else {
+			c.closeReason = closeReasonError
+		}
+		connEndHook()
+		c.tracer.end()
+	}
+}
+
+// setEndpointTags sets the endpoint tag, and the name and url tags unless grouped, to the url of the connection.
+func (c *Client) setEndpointTags() {
+	u, err := url.Parse(c.requestURL)
+	if err != nil {
+		return
+	}
+	if cleanURL, err := httpext.NewURL(c.requestURL, c.requestURL); err == nil {
+		c.tagsAndMeta.SetTag(endpointTagName, cleanURL.Clean())
+	}
+	if !c.urlGrouped {
+		setURLTags(c.tagsAndMeta, c.state.Options.SystemTags, u)
+	}
+}
+
+func isSuccess(resp *http.Response) bool {
+	return resp != nil && resp.StatusCode >= 200 && resp.StatusCode < 300
+}
+
+// resolveEndpoints returns the urls to request when sse.open() is given a list of urls, nil otherwise.
+func resolveEndpoints(state *lib.State, rt *sobek.Runtime, urlV sobek.Value, parsedArgs *sseOpenArgs) ([]string, error) {
+	list, ok := urlV.Export().([]interface{})
+	if !ok {
+		return nil, nil
+	}
+	if len(list) == 0 {
+		return nil, errNoEndpoint
+	}
+
+	// The tags are set from the first url, the ones of each connection are set once its endpoint is selected
+	first, err := resolveURL(state, rt.ToValue(list[0]), parsedArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := []string{first}
+	for _, v := range list[1:] {
+		u, err := httpext.ToURL(v)
+		if err != nil {
+			return nil, err
+		}
+		requestURL, err := withQuery(u.URL, parsedArgs.query)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, requestURL)
+	}
+	return urls, nil
+}
+
+func parseFailover(v sobek.Value) (string, error) {
+	switch strategy := strings.TrimSpace(v.String()); strategy {
+	case failoverRoundRobin, failoverRandom, failoverSticky:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown strategy %q", strategy)
+	}
+}
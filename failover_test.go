@@ -0,0 +1,139 @@
+package sse
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/metrics"
+)
+
+// sseEndpointHandler sends a single event with the name of the endpoint.
+func sseEndpointHandler(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: " + name + "\n\n"))
+	})
+}
+
+func TestEndpoints(t *testing.T) {
+	t.Parallel()
+
+	selected := func(e *endpoints, failed ...bool) []string {
+		var urls []string
+		for _, fail := range failed {
+			urls = append(urls, e.next())
+			if fail {
+				e.fail()
+			}
+		}
+		return urls
+	}
+
+	t.Run("round-robin", func(t *testing.T) {
+		t.Parallel()
+		e := newEndpoints([]string{"a", "b", "c"}, failoverRoundRobin)
+		assert.Equal(t, []string{"a", "b", "c", "a"}, selected(e, false, true, false, false))
+	})
+
+	t.Run("sticky", func(t *testing.T) {
+		t.Parallel()
+		e := newEndpoints([]string{"a", "b", "c"}, "")
+		assert.Equal(t, []string{"a", "a", "b", "b", "c", "a"}, selected(e, false, true, false, true, true, false))
+	})
+
+	t.Run("random", func(t *testing.T) {
+		t.Parallel()
+		e := newEndpoints([]string{"a", "b"}, failoverRandom)
+		previous := e.next()
+		for i := 0; i < 10; i++ {
+			e.fail()
+			current := e.next()
+			assert.NotEqual(t, previous, current, "a failed endpoint is not selected again")
+			previous = current
+		}
+	})
+}
+
+func TestFailover(t *testing.T) {
+	t.Parallel()
+
+	t.Run("on connection failure", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.HandleFunc("/sse-down", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+		test.tb.Mux.Handle("/sse-up", sseEndpointHandler("up"))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var events = []
+		var response = sse.open(["HTTPBIN_IP_URL/sse-down", "HTTPBIN_IP_URL/sse-up"], function(client){
+			client.on("event", function(event) { events.push(event.data) })
+		})
+		if (response.status != 200 || response.url != "HTTPBIN_IP_URL/sse-up") {
+			throw new Error("unexpected response: " + JSON.stringify(response))
+		}
+		if (events.join() != "up") {
+			throw new Error("unexpected events: " + events.join())
+		}
+		`))
+		require.NoError(t, err)
+
+		var endpoints, urls []string
+		for _, sampleContainer := range metrics.GetBufferedSamples(test.samples) {
+			for _, sample := range sampleContainer.GetSamples() {
+				if sample.Metric.Name == metrics.HTTPReqsName {
+					endpoint, _ := sample.Tags.Get(endpointTagName)
+					endpoints = append(endpoints, endpoint)
+					url, _ := sample.Tags.Get(metrics.TagURL.String())
+					urls = append(urls, url)
+				}
+			}
+		}
+		expected := []string{sr("HTTPBIN_IP_URL/sse-down"), sr("HTTPBIN_IP_URL/sse-up")}
+		assert.Equal(t, expected, endpoints)
+		assert.Equal(t, expected, urls)
+	})
+
+	t.Run("on disconnection", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-a", sseEndpointHandler("a"))
+		test.tb.Mux.Handle("/sse-b", sseEndpointHandler("b"))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var events = []
+		sse.open(["HTTPBIN_IP_URL/sse-a", "HTTPBIN_IP_URL/sse-b"], {
+			failover: "round-robin",
+			reconnect: {policy: "constant", delay: "1ms"},
+		}, function(client){
+			client.on("event", function(event) {
+				events.push(event.data)
+				if (events.length == 3) {
+					client.close()
+				}
+			})
+		})
+		if (events.join() != "a,b,a") {
+			throw new Error("unexpected events: " + events.join())
+		}
+		`))
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		_, err := test.VU.Runtime().RunString(sr(`sse.open(["HTTPBIN_IP_URL/sse"], {failover: "nearest"}, function(client){});`))
+		require.ErrorContains(t, err, `invalid sse.open() failover: unknown strategy "nearest"`)
+
+		_, err = test.VU.Runtime().RunString(`sse.open([], function(client){});`)
+		require.ErrorContains(t, err, errNoEndpoint.Error())
+	})
+}
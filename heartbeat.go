@@ -0,0 +1,86 @@
+package sse
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/sobek"
+)
+
+// ErrHeartbeatTimeout is raised when neither an event nor a comment is received within the heartbeat timeout.
+var ErrHeartbeatTimeout = errors.New("heartbeat timeout")
+
+type heartbeatOptions struct {
+	timeout time.Duration
+	close   bool
+}
+
+// heartbeat fires when the stream stays idle longer than the heartbeat timeout.
+// A nil heartbeat never fires.
+type heartbeat struct {
+	opts  *heartbeatOptions
+	timer *time.Timer
+}
+
+func newHeartbeat(opts *heartbeatOptions) *heartbeat {
+	if opts == nil || opts.timeout <= 0 {
+		return nil
+	}
+	return &heartbeat{
+		opts:  opts,
+		timer: time.NewTimer(opts.timeout),
+	}
+}
+
+// C returns the channel receiving the heartbeat timeouts.
+func (h *heartbeat) C() <-chan time.Time {
+	if h == nil {
+		return nil
+	}
+	return h.timer.C
+}
+
+// reset restarts the heartbeat window, it must be called on each stream activity.
+func (h *heartbeat) reset() {
+	if h == nil {
+		return
+	}
+	h.timer.Reset(h.opts.timeout)
+}
+
+func (h *heartbeat) stop() {
+	if h == nil {
+		return
+	}
+	h.timer.Stop()
+}
+
+func (h *heartbeat) err() error {
+	return fmt.Errorf("%w: nothing received within %s", ErrHeartbeatTimeout, h.opts.timeout)
+}
+
+func parseHeartbeatOption(k string, v sobek.Value, parsedArgs *sseOpenArgs) error {
+	if sobek.IsUndefined(v) || sobek.IsNull(v) {
+		return nil
+	}
+	if parsedArgs.heartbeat == nil {
+		parsedArgs.heartbeat = &heartbeatOptions{}
+	}
+
+	switch k {
+	case "heartbeatTimeout":
+		timeout, err := time.ParseDuration(v.String())
+		if err != nil {
+			return fmt.Errorf("invalid sse.open() heartbeatTimeout: %w", err)
+		}
+		if timeout <= 0 {
+			return errors.New("invalid sse.open() heartbeatTimeout: must be positive")
+		}
+		parsedArgs.heartbeat.timeout = timeout
+	case "closeOnHeartbeatTimeout":
+		parsedArgs.heartbeat.close = v.ToBoolean()
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package sse
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/metrics"
+)
+
+func TestHeartbeat(t *testing.T) {
+	t.Parallel()
+	test := newTestState(t)
+	sr := test.tb.Replacer.Replace
+
+	// Sends a keepalive comment then stays idle until the client leaves
+	test.tb.Mux.HandleFunc("/sse-idle", func(w http.ResponseWriter, req *http.Request) {
+		_, err := w.Write([]byte(": ping\n\n"))
+		require.NoError(t, err)
+		w.(http.Flusher).Flush() //nolint:forcetypeassert
+		<-req.Context().Done()
+	})
+
+	_, err := test.VU.Runtime().RunString(sr(`
+	var events = 0;
+	var comments = [];
+	var errors = [];
+	sse.open("HTTPBIN_IP_URL/sse-idle", {heartbeatTimeout: "100ms", closeOnHeartbeatTimeout: true}, function(client){
+		client.on("event", function(event) {
+			events++;
+		});
+		client.on("comment", function(comment) {
+			comments.push(comment);
+		});
+		client.on("error", function(err) {
+			errors.push(err.error());
+		});
+	});
+	if (events !== 0) {
+		throw new Error("comments must not be dispatched as events");
+	}
+	if (comments.length !== 1 || comments[0] !== "ping") {
+		throw new Error("unexpected comments: " + comments);
+	}
+	if (errors.length !== 1 || errors[0] !== "heartbeat timeout: nothing received within 100ms") {
+		throw new Error("unexpected errors: " + errors);
+	}
+	`))
+	require.NoError(t, err)
+
+	samplesBuf := metrics.GetBufferedSamples(test.samples)
+	url := sr("HTTPBIN_IP_URL/sse-idle")
+	assertMetricEmittedCount(t, MetricCommentsName, samplesBuf, url, 1)
+	assertSseCount(t, samplesBuf, url, 0)
+
+	_, err = test.VU.Runtime().RunString(sr(`
+	sse.open("HTTPBIN_IP_URL/sse-idle", {heartbeatTimeout: "soon"}, function(client){});
+	`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid sse.open() heartbeatTimeout")
+
+	for _, timeout := range []string{"0s", "-1s"} {
+		_, err = test.VU.Runtime().RunString(sr(`
+		sse.open("HTTPBIN_IP_URL/sse-idle", {heartbeatTimeout: "` + timeout + `"}, function(client){});
+		`))
+		require.ErrorContains(t, err, "invalid sse.open() heartbeatTimeout: must be positive")
+	}
+
+	_, err = test.VU.Runtime().RunString(sr(`
+	sse.open("HTTPBIN_IP_URL/sse-idle", {closeOnHeartbeatTimeout: true}, function(client){});
+	`))
+	require.ErrorContains(t, err, "invalid sse.open() closeOnHeartbeatTimeout: requires heartbeatTimeout")
+}
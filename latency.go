@@ -0,0 +1,123 @@
+package sse
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/sobek"
+	"github.com/tidwall/gjson"
+	"go.k6.io/k6/metrics"
+)
+
+// Fields of the event the embedded timestamp can be read from.
+const (
+	latencyFieldData = "data"
+	latencyFieldID   = "id"
+)
+
+type latencyOptions struct {
+	field string
+	path  string
+	unit  time.Duration
+}
+
+// timestamp extracts the publish time embedded by the server in the event.
+// Numeric timestamps are epochs expressed in the configured unit, strings which are
+// not numbers are parsed as RFC 3339 dates.
+func (o *latencyOptions) timestamp(ev Event) (time.Time, bool) {
+	raw := ev.Data
+	if o.field == latencyFieldID {
+		raw = ev.ID
+	}
+
+	if o.path != "" {
+		result := gjson.Get(raw, o.path)
+		if !result.Exists() {
+			return time.Time{}, false
+		}
+		raw = result.String()
+	}
+
+	raw = strings.TrimSpace(raw)
+	if epoch, err := strconv.ParseFloat(raw, 64); err == nil {
+		// Split the integral part to keep precision on large epochs
+		integral, frac := math.Modf(epoch)
+		if math.IsInf(epoch, 0) || math.IsNaN(epoch) || math.Abs(integral) > float64(math.MaxInt64/int64(o.unit)) {
+			return time.Time{}, false
+		}
+		ns := int64(integral)*int64(o.unit) + int64(math.Round(frac*float64(o.unit)))
+		return time.Unix(0, ns), true
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return ts, true
+}
+
+// pushEventLatency emits the delay between the embedded publish time of the event
+// and its reception. Events without a valid timestamp are ignored.
+func (c *Client) pushEventLatency(ev Event, received time.Time) {
+	if c.latency == nil {
+		return
+	}
+
+	published, ok := c.latency.timestamp(ev)
+	if !ok {
+		return
+	}
+
+	metrics.PushIfNotDone(c.ctx, c.samplesOutput, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: c.sseMetrics.SSEEventLatency,
+			Tags:   c.tagsAndMeta.Tags,
+		},
+		Time:     received,
+		Metadata: c.tagsAndMeta.Metadata,
+		Value:    metrics.D(received.Sub(published)),
+	})
+}
+
+func parseLatencyOptions(rt *sobek.Runtime, latencyV sobek.Value) (*latencyOptions, error) {
+	opts := &latencyOptions{
+		field: latencyFieldData,
+		unit:  time.Millisecond,
+	}
+
+	latencyObj := latencyV.ToObject(rt)
+	for _, k := range latencyObj.Keys() {
+		v := latencyObj.Get(k)
+		if sobek.IsUndefined(v) || sobek.IsNull(v) {
+			continue
+		}
+		switch k {
+		case "field":
+			field := strings.TrimSpace(v.String())
+			if field != latencyFieldData && field != latencyFieldID {
+				return nil, fmt.Errorf("unknown field %q", field)
+			}
+			opts.field = field
+		case "path":
+			opts.path = strings.TrimSpace(v.String())
+		case "unit":
+			switch unit := strings.TrimSpace(v.String()); unit {
+			case "s":
+				opts.unit = time.Second
+			case "ms":
+				opts.unit = time.Millisecond
+			case "us":
+				opts.unit = time.Microsecond
+			case "ns":
+				opts.unit = time.Nanosecond
+			default:
+				return nil, fmt.Errorf("unknown unit %q", unit)
+			}
+		}
+	}
+
+	return opts, nil
+}
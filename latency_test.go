@@ -0,0 +1,98 @@
+package sse
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/metrics"
+)
+
+func TestLatencyTimestamp(t *testing.T) {
+	t.Parallel()
+
+	published := time.Date(2024, 5, 1, 10, 0, 0, 250_000_000, time.UTC)
+
+	testCases := []struct {
+		name  string
+		opts  latencyOptions
+		event Event
+		ok    bool
+	}{
+		{
+			name:  "json path in ms",
+			opts:  latencyOptions{field: latencyFieldData, path: "meta.ts", unit: time.Millisecond},
+			event: Event{Data: fmt.Sprintf(`{"meta": {"ts": %d}}`, published.UnixMilli())},
+			ok:    true,
+		},
+		{
+			name:  "raw data in fractional seconds",
+			opts:  latencyOptions{field: latencyFieldData, unit: time.Second},
+			event: Event{Data: "1714557600.25"},
+			ok:    true,
+		},
+		{
+			name:  "rfc3339 id",
+			opts:  latencyOptions{field: latencyFieldID, unit: time.Millisecond},
+			event: Event{ID: published.Format(time.RFC3339Nano)},
+			ok:    true,
+		},
+		{
+			name:  "missing path",
+			opts:  latencyOptions{field: latencyFieldData, path: "ts", unit: time.Millisecond},
+			event: Event{Data: `{"other": 1}`},
+		},
+		{
+			name:  "not a timestamp",
+			opts:  latencyOptions{field: latencyFieldData, unit: time.Millisecond},
+			event: Event{Data: "hello"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ts, ok := tc.opts.timestamp(tc.event)
+			require.Equal(t, tc.ok, ok)
+			if ok {
+				assert.Equal(t, published.UnixMicro(), ts.UnixMicro())
+			}
+		})
+	}
+}
+
+func TestEventLatency(t *testing.T) {
+	t.Parallel()
+	test := newTestState(t)
+	sr := test.tb.Replacer.Replace
+
+	test.tb.Mux.HandleFunc("/sse-latency", func(w http.ResponseWriter, _ *http.Request) {
+		ts := time.Now().Add(-time.Second).UnixMilli()
+		_, err := w.Write([]byte(fmt.Sprintf("data: {\"ts\": %d}\n\ndata: {}\n\n", ts)))
+		require.NoError(t, err)
+	})
+
+	_, err := test.VU.Runtime().RunString(sr(`
+	sse.open("HTTPBIN_IP_URL/sse-latency", {latency: {path: "ts", unit: "ms"}}, function(client){});
+	`))
+	require.NoError(t, err)
+
+	samplesBuf := metrics.GetBufferedSamples(test.samples)
+	assertMetricEmittedCount(t, MetricEventLatencyName, samplesBuf, sr("HTTPBIN_IP_URL/sse-latency"), 1)
+	for _, sampleContainer := range samplesBuf {
+		for _, sample := range sampleContainer.GetSamples() {
+			if sample.Metric.Name == MetricEventLatencyName {
+				assert.GreaterOrEqual(t, sample.Value, 1000.0)
+			}
+		}
+	}
+
+	_, err = test.VU.Runtime().RunString(sr(`
+	sse.open("HTTPBIN_IP_URL/sse-latency", {latency: {unit: "minutes"}}, function(client){});
+	`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid sse.open() latency")
+}
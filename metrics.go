@@ -7,11 +7,70 @@ import (
 	"go.k6.io/k6/metrics"
 )
 
-// MetricEventName is the sse event metric of the module
-const MetricEventName = "sse_event"
+const (
+	// MetricEventName is the sse event metric of the module
+	MetricEventName = "sse_event"
+	// MetricEventLatencyName is the delay between the server embedded timestamp and the event reception
+	MetricEventLatencyName = "sse_event_latency"
+	// MetricProbeDeliveryName is the delay between the probe publish and the event delivery
+	MetricProbeDeliveryName = "sse_probe_delivery"
+	// MetricProbeFailedName is the rate of probes whose event was not delivered
+	MetricProbeFailedName = "sse_probe_failed"
+	// MetricEventGapsName is the number of event ids missing in the sequence
+	MetricEventGapsName = "sse_event_gaps"
+	// MetricEventDuplicatesName is the number of events whose id is one of the last 1024 ids received
+	MetricEventDuplicatesName = "sse_event_duplicates"
+	// MetricCommentsName is the number of comment lines received
+	MetricCommentsName = "sse_comments"
+	// MetricWireBytesName is the number of bytes of the stream received on the wire
+	MetricWireBytesName = "sse_wire_bytes"
+	// MetricDecodedBytesName is the number of bytes of the stream once decoded
+	MetricDecodedBytesName = "sse_decoded_bytes"
+	// MetricBytesReceivedName is the number of bytes received on the connection of the stream
+	MetricBytesReceivedName = "sse_bytes_received"
+	// MetricActiveStreamsName is the number of streams currently open
+	MetricActiveStreamsName = "sse_active_streams"
+	// MetricStreamAgeName is the time elapsed since the streams were opened, pushed while they are open
+	MetricStreamAgeName = "sse_stream_age"
+	// MetricReconnectsName is the number of reconnection attempts
+	MetricReconnectsName = "sse_reconnects"
+	// MetricReconnectDelayName is the delay waited before the reconnection attempts
+	MetricReconnectDelayName = "sse_reconnect_delay"
+	// MetricThrottledName is the number of connections rejected with 429 or 503
+	MetricThrottledName = "sse_throttled"
+	// MetricHandlerDurationName is the time spent in the event handlers per handler call, once per event or per batch
+	MetricHandlerDurationName = "sse_handler_duration"
+	// MetricDispatchDelayName is the delay between the event parsing and the start of its handlers
+	MetricDispatchDelayName = "sse_dispatch_delay"
+	// MetricEventsDroppedName is the number of events dropped by the queue once full
+	MetricEventsDroppedName = "sse_events_dropped"
+	// MetricOversizedEventsName is the number of lines or events exceeding maxLineBytes or maxEventBytes
+	MetricOversizedEventsName = "sse_oversized_events"
+	// MetricProtocolViolationsName is the number of deviations from the SSE specification in strict validation
+	MetricProtocolViolationsName = "sse_protocol_violations"
+)
 
 type sseMetrics struct {
-	SSEEventReceived *metrics.Metric
+	SSEEventReceived      *metrics.Metric
+	SSEEventLatency       *metrics.Metric
+	SSEProbeDelivery      *metrics.Metric
+	SSEProbeFailed        *metrics.Metric
+	SSEEventGaps          *metrics.Metric
+	SSEEventDuplicates    *metrics.Metric
+	SSEComments           *metrics.Metric
+	SSEWireBytes          *metrics.Metric
+	SSEDecodedBytes       *metrics.Metric
+	SSEBytesReceived      *metrics.Metric
+	SSEActiveStreams      *metrics.Metric
+	SSEStreamAge          *metrics.Metric
+	SSEReconnects         *metrics.Metric
+	SSEReconnectDelay     *metrics.Metric
+	SSEThrottled          *metrics.Metric
+	SSEHandlerDuration    *metrics.Metric
+	SSEDispatchDelay      *metrics.Metric
+	SSEEventsDropped      *metrics.Metric
+	SSEOversizedEvents    *metrics.Metric
+	SSEProtocolViolations *metrics.Metric
 }
 
 // registerMetrics registers the metrics for the sse module in the metrics registry
@@ -32,5 +91,100 @@ func registerMetrics(vu modules.VU) (sseMetrics, error) {
 		return m, err
 	}
 
+	m.SSEEventLatency, err = registry.NewMetric(MetricEventLatencyName, metrics.Trend, metrics.Time)
+	if err != nil {
+		return m, err
+	}
+
+	m.SSEProbeDelivery, err = registry.NewMetric(MetricProbeDeliveryName, metrics.Trend, metrics.Time)
+	if err != nil {
+		return m, err
+	}
+
+	m.SSEProbeFailed, err = registry.NewMetric(MetricProbeFailedName, metrics.Rate)
+	if err != nil {
+		return m, err
+	}
+
+	m.SSEEventGaps, err = registry.NewMetric(MetricEventGapsName, metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+
+	m.SSEEventDuplicates, err = registry.NewMetric(MetricEventDuplicatesName, metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+
+	m.SSEComments, err = registry.NewMetric(MetricCommentsName, metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+
+	m.SSEWireBytes, err = registry.NewMetric(MetricWireBytesName, metrics.Counter, metrics.Data)
+	if err != nil {
+		return m, err
+	}
+
+	m.SSEDecodedBytes, err = registry.NewMetric(MetricDecodedBytesName, metrics.Counter, metrics.Data)
+	if err != nil {
+		return m, err
+	}
+
+	m.SSEBytesReceived, err = registry.NewMetric(MetricBytesReceivedName, metrics.Counter, metrics.Data)
+	if err != nil {
+		return m, err
+	}
+
+	m.SSEActiveStreams, err = registry.NewMetric(MetricActiveStreamsName, metrics.Gauge)
+	if err != nil {
+		return m, err
+	}
+
+	m.SSEStreamAge, err = registry.NewMetric(MetricStreamAgeName, metrics.Trend, metrics.Time)
+	if err != nil {
+		return m, err
+	}
+
+	m.SSEReconnects, err = registry.NewMetric(MetricReconnectsName, metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+
+	m.SSEReconnectDelay, err = registry.NewMetric(MetricReconnectDelayName, metrics.Trend, metrics.Time)
+	if err != nil {
+		return m, err
+	}
+
+	m.SSEThrottled, err = registry.NewMetric(MetricThrottledName, metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+
+	m.SSEHandlerDuration, err = registry.NewMetric(MetricHandlerDurationName, metrics.Trend, metrics.Time)
+	if err != nil {
+		return m, err
+	}
+
+	m.SSEDispatchDelay, err = registry.NewMetric(MetricDispatchDelayName, metrics.Trend, metrics.Time)
+	if err != nil {
+		return m, err
+	}
+
+	m.SSEEventsDropped, err = registry.NewMetric(MetricEventsDroppedName, metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+
+	m.SSEOversizedEvents, err = registry.NewMetric(MetricOversizedEventsName, metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+
+	m.SSEProtocolViolations, err = registry.NewMetric(MetricProtocolViolationsName, metrics.Counter)
+	if err != nil {
+		return m, err
+	}
+
 	return m, nil
 }
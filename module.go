@@ -1,6 +1,8 @@
 package sse
 
 import (
+	"sync/atomic"
+
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/modules"
 )
@@ -8,7 +10,10 @@ import (
 type (
 	// RootModule is the global module instance that will create module
 	// instances for each VU.
-	RootModule struct{}
+	RootModule struct {
+		// activeStreams is the number of streams open across all the VUs of the k6 instance.
+		activeStreams atomic.Int64
+	}
 )
 
 var (
@@ -23,16 +28,23 @@ func New() *RootModule {
 
 // NewModuleInstance implements the modules.Module interface to return
 // a new instance for each VU.
-func (*RootModule) NewModuleInstance(m modules.VU) modules.Instance {
+func (r *RootModule) NewModuleInstance(m modules.VU) modules.Instance {
 	rt := m.Runtime()
 	mi := &sse{
-		vu: m,
+		vu:            m,
+		activeStreams: &r.activeStreams,
 	}
 
 	obj := rt.NewObject()
 	if err := obj.Set("open", mi.Open); err != nil {
 		common.Throw(rt, err)
 	}
+	if err := obj.Set("probe", mi.Probe); err != nil {
+		common.Throw(rt, err)
+	}
+	if err := obj.Set("expectStream", mi.ExpectStream); err != nil {
+		common.Throw(rt, err)
+	}
 
 	mi.obj = obj
 
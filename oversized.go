@@ -0,0 +1,71 @@
+package sse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/sobek"
+	"go.k6.io/k6/metrics"
+)
+
+// ErrEventTooLarge is raised when a line or an event of the stream exceeds maxLineBytes or maxEventBytes.
+var ErrEventTooLarge = errors.New("event_too_large")
+
+// Default limits of the lines and events of a stream, so a server never sending a newline
+// cannot exhaust the memory of the load generator.
+const (
+	defaultMaxLineBytes  = 1 << 20
+	defaultMaxEventBytes = 8 << 20
+)
+
+// Policies of the oversized param.
+const (
+	oversizedSkip  = "skip"
+	oversizedAbort = "abort"
+)
+
+// parserLimits bounds the size of the lines and events read by the parser.
+type parserLimits struct {
+	maxLineBytes  int
+	maxEventBytes int
+}
+
+var defaultParserLimits = parserLimits{ //nolint:gochecknoglobals
+	maxLineBytes:  defaultMaxLineBytes,
+	maxEventBytes: defaultMaxEventBytes,
+}
+
+// checkOversized pushes sse_oversized_events if the error is raised by an oversized line or event.
+func (c *Client) checkOversized(err error) {
+	if !errors.Is(err, ErrEventTooLarge) {
+		return
+	}
+	metrics.PushIfNotDone(c.ctx, c.samplesOutput, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: c.sseMetrics.SSEOversizedEvents,
+			Tags:   c.tagsAndMeta.Tags,
+		},
+		Time:     time.Now(),
+		Metadata: c.tagsAndMeta.Metadata,
+		Value:    1,
+	})
+}
+
+func parseSizeLimit(v sobek.Value) (int, error) {
+	limit := v.ToInteger()
+	if limit <= 0 {
+		return 0, errors.New("must be positive")
+	}
+	return int(limit), nil
+}
+
+func parseOversized(v sobek.Value) (string, error) {
+	switch policy := strings.TrimSpace(v.String()); policy {
+	case oversizedSkip, oversizedAbort:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown policy %q", policy)
+	}
+}
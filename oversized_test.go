@@ -0,0 +1,158 @@
+package sse
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/metrics"
+)
+
+// endlessReader returns the same byte forever.
+type endlessReader byte
+
+func (r endlessReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+	return len(p), nil
+}
+
+func TestParserLimits(t *testing.T) {
+	t.Parallel()
+	limits := parserLimits{maxLineBytes: 8, maxEventBytes: 16}
+
+	parse := func(t *testing.T, r io.Reader) []token {
+		p := newEventParser(r, limits)
+		defer p.release()
+
+		var tokens []token
+		for {
+			tok, err := p.next()
+			if errors.Is(err, io.EOF) {
+				return tokens
+			}
+			require.NoError(t, err)
+			tokens = append(tokens, tok)
+		}
+	}
+
+	t.Run("line", func(t *testing.T) {
+		t.Parallel()
+		tokens := parse(t, strings.NewReader("data: 0123456789abcdef\r\ndata: next\r\n\r\ndata: ok\r\n\r\n"))
+		require.Len(t, tokens, 2)
+		assert.Equal(t, tokenOversized, tokens[0].kind)
+		require.ErrorIs(t, tokens[0].err, ErrEventTooLarge)
+		assert.EqualError(t, tokens[0].err, "event_too_large: line longer than maxLineBytes 8")
+		assert.Equal(t, Event{Data: "ok"}, tokens[1].event, "the oversized event is skipped")
+	})
+
+	t.Run("event", func(t *testing.T) {
+		t.Parallel()
+		tokens := parse(t, strings.NewReader(strings.Repeat("data:123\n", 5)+"\ndata: ok\n\n"))
+		require.Len(t, tokens, 2)
+		assert.EqualError(t, tokens[0].err, "event_too_large: event larger than maxEventBytes 16")
+		assert.Equal(t, Event{Data: "ok"}, tokens[1].event)
+	})
+
+	t.Run("line never ending", func(t *testing.T) {
+		t.Parallel()
+		tokens := parse(t, io.MultiReader(
+			strings.NewReader("data: "),
+			io.LimitReader(endlessReader('x'), 1<<20),
+			bytes.NewReader([]byte("\n\ndata: ok\n\n")),
+		))
+		require.Len(t, tokens, 2)
+		assert.Equal(t, tokenOversized, tokens[0].kind)
+		assert.Equal(t, Event{Data: "ok"}, tokens[1].event)
+	})
+
+	t.Run("line ending the stream", func(t *testing.T) {
+		t.Parallel()
+		tokens := parse(t, strings.NewReader("data: ok\n\n"+strings.Repeat("x", 100)))
+		require.Len(t, tokens, 2)
+		assert.Equal(t, Event{Data: "ok"}, tokens[0].event)
+		assert.Equal(t, tokenOversized, tokens[1].kind)
+	})
+}
+
+func TestOversized(t *testing.T) {
+	t.Parallel()
+
+	oversizedHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: " + strings.Repeat("x", 100) + "\n\ndata: ok\n\n"))
+	})
+
+	oversizedEvents := func(test testState) float64 {
+		var oversized float64
+		for _, sampleContainer := range metrics.GetBufferedSamples(test.samples) {
+			for _, sample := range sampleContainer.GetSamples() {
+				if sample.Metric.Name == MetricOversizedEventsName {
+					oversized += sample.Value
+				}
+			}
+		}
+		return oversized
+	}
+
+	t.Run("skip", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-oversized", oversizedHandler)
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var events = [], errors = []
+		sse.open("HTTPBIN_IP_URL/sse-oversized", {maxLineBytes: 64}, function(client){
+			client.on("event", function(event) { events.push(event.data) })
+			client.on("error", function(e) { errors.push(e.error()) })
+		})
+		if (events.join() != "ok") {
+			throw new Error("unexpected events: " + events.join())
+		}
+		if (errors.join() != "event_too_large: line longer than maxLineBytes 64") {
+			throw new Error("unexpected errors: " + errors.join())
+		}
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, float64(1), oversizedEvents(test))
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-oversized", oversizedHandler)
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var events = [], reason
+		sse.open("HTTPBIN_IP_URL/sse-oversized", {maxEventBytes: 64, oversized: "abort"}, function(client){
+			client.on("event", function(event) { events.push(event.data) })
+			client.on("close", function(e) { reason = e.reason })
+		})
+		if (events.length != 0 || reason != "error") {
+			throw new Error("the stream is not aborted: " + events.join() + " " + reason)
+		}
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, float64(1), oversizedEvents(test))
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		_, err := test.VU.Runtime().RunString(sr(`sse.open("HTTPBIN_IP_URL/sse", {maxLineBytes: 0}, function(client){});`))
+		require.ErrorContains(t, err, "invalid sse.open() maxLineBytes: must be positive")
+
+		_, err = test.VU.Runtime().RunString(sr(`sse.open("HTTPBIN_IP_URL/sse", {oversized: "truncate"}, function(client){});`))
+		require.ErrorContains(t, err, `invalid sse.open() oversized: unknown policy "truncate"`)
+	})
+}
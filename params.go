@@ -0,0 +1,103 @@
+package sse
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+
+	"github.com/grafana/sobek"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/netext/httpext"
+	"go.k6.io/k6/metrics"
+)
+
+var errBodyAlreadySet = errors.New("only one of body, json or form can be set")
+
+// resolveURL returns the url to request and sets its name and url tags as k6/http does.
+// The url is either a string or an http.url tagged template. A name tag set by the user
+// or from the template is used as the url tag too, to prevent high-cardinality values.
+func resolveURL(state *lib.State, urlV sobek.Value, parsedArgs *sseOpenArgs) (string, error) {
+	u, err := httpext.ToURL(urlV.Export())
+	if err != nil {
+		return "", err
+	}
+
+	requestURL, err := withQuery(u.URL, parsedArgs.query)
+	if err != nil {
+		return "", err
+	}
+
+	tagsAndMeta := parsedArgs.tagsAndMeta
+	enabledTags := state.Options.SystemTags
+	if _, ok := tagsAndMeta.Tags.Get(metrics.TagName.String()); !ok && u.Name != "" && u.Name != u.Clean() {
+		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagName, u.Name)
+	}
+
+	if name, ok := tagsAndMeta.Tags.Get(metrics.TagName.String()); ok {
+		parsedArgs.urlGrouped = true
+		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagURL, name)
+	} else {
+		cleanURL, err := httpext.NewURL(requestURL, requestURL)
+		if err != nil {
+			return "", err
+		}
+		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagName, cleanURL.Clean())
+		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagURL, cleanURL.Clean())
+	}
+
+	return requestURL, nil
+}
+
+// withQuery returns the url with the query parameters merged into its own.
+func withQuery(rawURL string, query url.Values) (string, error) {
+	if len(query) == 0 {
+		return rawURL, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	values := u.Query()
+	for k, vs := range query {
+		values[k] = vs
+	}
+	u.RawQuery = values.Encode()
+
+	return u.String(), nil
+}
+
+// toValues converts an object to url values, arrays are converted to multiple values.
+func toValues(rt *sobek.Runtime, v sobek.Value) url.Values {
+	values := make(url.Values)
+	obj := v.ToObject(rt)
+	for _, k := range obj.Keys() {
+		value := obj.Get(k)
+		if sobek.IsUndefined(value) || sobek.IsNull(value) {
+			continue
+		}
+		if items, ok := value.Export().([]any); ok {
+			for _, item := range items {
+				values.Add(k, rt.ToValue(item).String())
+			}
+			continue
+		}
+		values.Add(k, value.String())
+	}
+	return values
+}
+
+func parseJSONBody(v sobek.Value) (*requestBody, error) {
+	data, err := json.Marshal(v.Export())
+	if err != nil {
+		return nil, err
+	}
+	return &requestBody{data: data, contentType: "application/json"}, nil
+}
+
+func parseFormBody(rt *sobek.Runtime, v sobek.Value) *requestBody {
+	return &requestBody{
+		data:        []byte(toValues(rt, v).Encode()),
+		contentType: "application/x-www-form-urlencoded",
+	}
+}
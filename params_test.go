@@ -0,0 +1,151 @@
+package sse
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	httpModule "go.k6.io/k6/js/modules/k6/http"
+	"go.k6.io/k6/metrics"
+)
+
+func TestParamsHelpers(t *testing.T) {
+	t.Parallel()
+
+	t.Run("query", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.HandleFunc("/sse-echo-query", func(w http.ResponseWriter, req *http.Request) {
+			_, err := w.Write([]byte("data: " + req.URL.RawQuery + "\n\n"))
+			require.NoError(t, err)
+		})
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var data = null;
+		var res = sse.open("HTTPBIN_IP_URL/sse-echo-query?topic=a&page=1", {query: {topic: "b", ids: [1, 2]}}, function(client){
+			client.on("event", function(event) {
+				data = event.data;
+			});
+		});
+		if (data !== "ids=1&ids=2&page=1&topic=b") {
+			throw new Error("unexpected query: " + data);
+		}
+		`))
+		require.NoError(t, err)
+		assertSseCount(t, metrics.GetBufferedSamples(test.samples), sr("HTTPBIN_IP_URL/sse-echo-query?ids=1&ids=2&page=1&topic=b"), 1)
+	})
+
+	testCases := []struct {
+		name        string
+		params      string
+		expected    string
+		contentType string
+	}{
+		{
+			name:        "json",
+			params:      `{method: "POST", json: {prompt: "hello", stream: true}}`,
+			expected:    `{"prompt":"hello","stream":true}`,
+			contentType: "application/json",
+		},
+		{
+			name:        "json with custom content type",
+			params:      `{method: "POST", json: ["a"], headers: {"Content-Type": "application/vnd.api+json"}}`,
+			expected:    `["a"]`,
+			contentType: "application/vnd.api+json",
+		},
+		{
+			name:        "form",
+			params:      `{method: "POST", form: {user: "k6 sse", roles: ["a", "b"]}}`,
+			expected:    `roles=a&roles=b&user=k6+sse`,
+			contentType: "application/x-www-form-urlencoded",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			test := newTestState(t)
+			sr := test.tb.Replacer.Replace
+			test.tb.Mux.HandleFunc("/sse-echo-raw-body", func(w http.ResponseWriter, req *http.Request) {
+				body, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				w.Header().Set("Echo-Content-Type", req.Header.Get("Content-Type"))
+				_, err = w.Write([]byte("data: " + string(body) + "\n\n"))
+				require.NoError(t, err)
+			})
+
+			rt := test.VU.Runtime()
+			require.NoError(t, rt.Set("expected", tc.expected))
+			require.NoError(t, rt.Set("contentType", tc.contentType))
+
+			_, err := rt.RunString(sr(`
+			var data = null;
+			var res = sse.open("HTTPBIN_IP_URL/sse-echo-raw-body", ` + tc.params + `, function(client){
+				client.on("event", function(event) {
+					data = event.data;
+				});
+			});
+			if (data !== expected) {
+				throw new Error("unexpected body: " + data);
+			}
+			if (res.headers["Echo-Content-Type"] !== contentType) {
+				throw new Error("unexpected content type: " + res.headers["Echo-Content-Type"]);
+			}
+			`))
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("url grouping", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.HandleFunc("/sse-user/", func(w http.ResponseWriter, req *http.Request) {
+			_, err := w.Write([]byte("data: " + req.URL.Path + "\n\n"))
+			require.NoError(t, err)
+		})
+		require.NoError(t, test.VU.Runtime().Set("http", httpModule.New().NewModuleInstance(test.VU).Exports().Default))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var data = [];
+		for (var id of [123, 456]) {
+			var res = sse.open(http.url` + "`HTTPBIN_IP_URL/sse-user/${id}`" + `, function(client){
+				client.on("event", function(event) {
+					data.push(event.data);
+				});
+			});
+			if (res.url !== "HTTPBIN_IP_URL/sse-user/" + id) {
+				throw new Error("unexpected url: " + res.url);
+			}
+		}
+		sse.open("HTTPBIN_IP_URL/sse-user/789", {tags: {name: "user-events"}}, function(client){
+			client.on("event", function(event) {
+				data.push(event.data);
+			});
+		});
+		if (data.join() !== "/sse-user/123,/sse-user/456,/sse-user/789") {
+			throw new Error("unexpected data: " + data);
+		}
+		`))
+		require.NoError(t, err)
+
+		samplesBuf := metrics.GetBufferedSamples(test.samples)
+		assertSseCount(t, samplesBuf, sr("HTTPBIN_IP_URL/sse-user/${}"), 2)
+		assertSseCount(t, samplesBuf, "user-events", 1)
+	})
+
+	t.Run("body conflict", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		sse.open("HTTPBIN_IP_URL/sse", {body: "a", json: {a: 1}}, function(client){});
+		`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "only one of body, json or form can be set")
+	})
+}
@@ -0,0 +1,369 @@
+package sse
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+)
+
+// The parser reads the stream with a scanner over a reusable buffer: the lines are not allocated,
+// only the fields of the events dispatched are copied to strings, and the buffers are pooled across
+// the connections. Run `go test -bench Parser -benchmem` to check the throughput, the target is
+// at least 1M small token events/s per core, so the parsing is not the bottleneck of a load generator.
+
+// tokenKind is the kind of the token read by the parser.
+type tokenKind int
+
+const (
+	tokenEvent tokenKind = iota
+	tokenComment
+	tokenRetry
+	tokenInvalid
+	tokenOversized
+	tokenViolation
+)
+
+// token is either an event, a comment, a retry hint, an invalid line, an oversized line or event,
+// or a protocol violation in strict validation.
+type token struct {
+	kind      tokenKind
+	event     Event
+	comment   string
+	retry     int64
+	line      string
+	err       error
+	violation ProtocolViolation
+}
+
+// Line endings of the stream, tracked in strict validation.
+const (
+	lineEndingLF = iota + 1
+	lineEndingCRLF
+	lineEndingCR
+)
+
+// bom is the UTF-8 byte order mark, ignored at the start of the stream.
+var bom = []byte("\uFEFF") //nolint:gochecknoglobals
+
+// parserBufferSize is the initial size of the scanner buffer, it grows up to the longest line.
+const parserBufferSize = 4096
+
+var parserPool = sync.Pool{ //nolint:gochecknoglobals
+	New: func() any {
+		return &eventParser{
+			buf:  make([]byte, parserBufferSize),
+			data: make([]byte, 0, parserBufferSize),
+		}
+	},
+}
+
+// eventParser parses the SSE format described in:
+// https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation
+type eventParser struct {
+	scanner *bufio.Scanner
+	buf     []byte
+	limits  parserLimits
+
+	// lineTooLong is set once a line exceeds maxLineBytes, its remaining bytes are then skipped.
+	// The scanner stops on a split without token at the end of the stream, so the skipped bytes
+	// are returned as an empty token flagged as skipped.
+	lineTooLong  bool
+	skippingLine bool
+	skipped      bool
+	// discarding is set once the event is oversized, its remaining lines are then discarded
+	discarding bool
+
+	// The violations found in strict validation, returned before the next token
+	validate         bool
+	lines            int64
+	lineEnding       int
+	mixedEndings     bool
+	unterminatedLine bool
+	ended            bool
+	pending          []ProtocolViolation
+
+	// The fields of the event being parsed
+	id        []byte
+	hasID     bool
+	comment   []byte
+	name      []byte
+	data      []byte
+	hasFields bool
+
+	// lastName is the name of the previous event, reused as most streams send a few distinct names
+	lastName string
+}
+
+// newEventParser returns a pooled parser of the stream, it must be released once the stream is read.
+func newEventParser(r io.Reader, limits parserLimits) *eventParser {
+	p := parserPool.Get().(*eventParser) //nolint:forcetypeassert
+	p.limits = limits
+	p.scanner = bufio.NewScanner(r)
+	// The buffer holds the longest line with its line ending
+	p.scanner.Buffer(p.buf, limits.maxLineBytes+2)
+	p.scanner.Split(p.scanLines)
+	p.reset()
+	p.lineTooLong, p.skippingLine, p.skipped, p.discarding = false, false, false, false
+	p.validate, p.lines, p.lineEnding, p.mixedEndings, p.unterminatedLine, p.ended = false, 0, 0, false, false, false
+	p.pending = p.pending[:0]
+	p.lastName = ""
+	return p
+}
+
+// release returns the parser to the pool.
+func (p *eventParser) release() {
+	p.scanner = nil
+	parserPool.Put(p)
+}
+
+func (p *eventParser) reset() {
+	p.id = p.id[:0]
+	p.hasID = false
+	p.comment = p.comment[:0]
+	p.name = p.name[:0]
+	p.data = p.data[:0]
+	p.hasFields = false
+}
+
+// next returns the next token of the stream, io.EOF once the stream ended.
+// An incomplete event at the end of the stream is discarded.
+func (p *eventParser) next() (token, error) {
+	for {
+		if len(p.pending) > 0 {
+			v := p.pending[0]
+			p.pending = p.pending[1:]
+			return token{kind: tokenViolation, violation: v}, nil
+		}
+		if !p.scanner.Scan() {
+			break
+		}
+		line := p.scanner.Bytes()
+
+		if p.skipped {
+			p.skipped = false
+			continue
+		}
+		p.lines++
+		if p.lines == 1 {
+			line = bytes.TrimPrefix(line, bom)
+		}
+		if p.validate && bytes.Contains(line, bom) {
+			p.violation(ruleBOMMidStream, "byte order mark in the middle of the stream")
+		}
+		if p.lineTooLong {
+			p.lineTooLong = false
+			if !p.discarding {
+				return p.oversized(fmt.Errorf("%w: line longer than maxLineBytes %d", ErrEventTooLarge, p.limits.maxLineBytes)), nil
+			}
+			continue
+		}
+
+		// The lines of an oversized event are discarded up to the blank line ending it
+		if p.discarding {
+			if len(line) == 0 {
+				p.discarding = false
+			}
+			continue
+		}
+
+		// Blank lines dispatch the event, blocks made only of comments or retry hints are not events
+		if len(line) == 0 {
+			if !p.hasFields {
+				p.comment = p.comment[:0]
+				continue
+			}
+			ev := p.event()
+			p.reset()
+			return token{kind: tokenEvent, event: ev}, nil
+		}
+
+		// Comments are dispatched on their own, they are mostly used as keepalive
+		if line[0] == ':' {
+			p.comment = append(p.comment[:0], trimSpace(line[1:])...)
+			return token{kind: tokenComment, comment: string(p.comment)}, nil
+		}
+
+		field, value := line, []byte(nil)
+		if i := bytes.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], trimSpace(line[i+1:])
+		}
+
+		switch string(field) { // the conversion is optimized away by the compiler
+		case "id":
+			p.id = append(p.id[:0], value...)
+			p.hasID = true
+		case "event":
+			p.name = append(p.name[:0], value...)
+		case "data":
+			if len(p.data)+len(value)+1 > p.limits.maxEventBytes {
+				return p.oversized(fmt.Errorf("%w: event larger than maxEventBytes %d", ErrEventTooLarge, p.limits.maxEventBytes)), nil
+			}
+			p.data = append(p.data, value...)
+			p.data = append(p.data, '\n')
+		case "retry":
+			// The reconnection time hint of the server, ignored if not an integer
+			if retry, ok := parseRetry(value); ok {
+				return token{kind: tokenRetry, retry: retry}, nil
+			}
+			if p.validate {
+				p.violation(ruleInvalidRetry, fmt.Sprintf("retry %q is not made only of digits", value))
+			}
+			continue
+		default:
+			// Unknown fields are reported as errors, or only recorded in strict validation
+			if p.validate {
+				p.violation(ruleUnknownField, fmt.Sprintf("unknown field %q", field))
+				continue
+			}
+			return token{kind: tokenInvalid, line: string(line)}, nil
+		}
+		p.hasFields = true
+	}
+
+	if err := p.scanner.Err(); err != nil {
+		return token{}, err
+	}
+	if p.validate && !p.ended && (p.hasFields || p.unterminatedLine) {
+		p.ended = true
+		p.lines++
+		return token{kind: tokenViolation, violation: ProtocolViolation{
+			Rule:    ruleUnterminatedEvent,
+			Line:    p.lines,
+			Message: "the stream ended without the blank line dispatching the last event",
+		}}, nil
+	}
+	return token{}, io.EOF
+}
+
+// violation records a violation at the current line, returned before the next token.
+func (p *eventParser) violation(rule, message string) {
+	p.pending = append(p.pending, ProtocolViolation{Rule: rule, Line: p.lines, Message: message})
+}
+
+// checkLineEnding records a violation once the stream mixes line endings.
+func (p *eventParser) checkLineEnding(lineEnding int) {
+	switch {
+	case !p.validate || p.mixedEndings:
+	case p.lineEnding == 0:
+		p.lineEnding = lineEnding
+	case p.lineEnding != lineEnding:
+		p.mixedEndings = true
+		// The line is counted once scanned
+		p.pending = append(p.pending, ProtocolViolation{
+			Rule:    ruleMixedLineEndings,
+			Line:    p.lines + 1,
+			Message: "the stream mixes CRLF, LF and CR line endings",
+		})
+	}
+}
+
+// oversized discards the event being parsed and returns the oversized token.
+func (p *eventParser) oversized(err error) token {
+	p.reset()
+	p.discarding = true
+	return token{kind: tokenOversized, err: err}
+}
+
+// event returns the event parsed, trailing newlines are removed from its data.
+// Its fields are copied out of the reused buffers, as the handlers may keep the event.
+func (p *eventParser) event() Event {
+	ev := Event{Data: string(bytes.TrimRight(p.data, "\r\n"))}
+	if p.hasID {
+		ev.ID = string(p.id)
+	}
+	if len(p.comment) > 0 {
+		ev.Comment = string(p.comment)
+	}
+	if len(p.name) > 0 {
+		if string(p.name) != p.lastName {
+			p.lastName = string(p.name)
+		}
+		ev.Name = p.lastName
+	}
+	return ev
+}
+
+// trimSpace removes the single leading space of a field value.
+func trimSpace(value []byte) []byte {
+	if len(value) > 0 && value[0] == ' ' {
+		return value[1:]
+	}
+	return value
+}
+
+// parseRetry parses the retry hint made only of ASCII digits.
+func parseRetry(value []byte) (int64, bool) {
+	if len(value) == 0 {
+		return 0, false
+	}
+	var retry int64
+	for _, b := range value {
+		if b < '0' || b > '9' || retry > (math.MaxInt64-9)/10 {
+			return 0, false
+		}
+		retry = retry*10 + int64(b-'0')
+	}
+	return retry, true
+}
+
+// scanLines splits the stream on the CRLF, LF and CR line endings, without the line ending.
+// A line longer than maxLineBytes is reported as soon as the limit is reached, and its bytes are skipped.
+func (p *eventParser) scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	i := bytes.IndexAny(data, "\r\n")
+	switch {
+	case p.skippingLine && atEOF && len(data) == 0:
+		// The stream ended while skipping the line, nothing is left to skip
+		return 0, nil, nil
+	case p.skippingLine && i < 0:
+		p.skipped = true
+		return len(data), data[:0], nil
+	case p.skippingLine:
+		return p.skipLineEnding(data, i, atEOF)
+	case i > p.limits.maxLineBytes || (i < 0 && len(data) > p.limits.maxLineBytes):
+		// The line is reported once, its bytes up to the line ending are skipped
+		p.lineTooLong = true
+		p.skippingLine = true
+		if i < 0 {
+			return len(data), data[:0], nil
+		}
+		return i, data[:0], nil
+	case i < 0:
+		// The line is incomplete, an incomplete line at the end of the stream is discarded
+		p.unterminatedLine = atEOF && len(data) > 0
+		return 0, nil, nil
+	case data[i] == '\n':
+		p.checkLineEnding(lineEndingLF)
+		return i + 1, data[:i], nil
+	case i+1 < len(data):
+		if data[i+1] == '\n' {
+			p.checkLineEnding(lineEndingCRLF)
+			return i + 2, data[:i], nil
+		}
+		p.checkLineEnding(lineEndingCR)
+		return i + 1, data[:i], nil
+	case atEOF:
+		p.checkLineEnding(lineEndingCR)
+		return i + 1, data[:i], nil
+	default:
+		// A CR at the end of the buffer may be followed by a LF
+		return 0, nil, nil
+	}
+}
+
+// skipLineEnding skips the end of a line longer than maxLineBytes, up to its line ending at i.
+func (p *eventParser) skipLineEnding(data []byte, i int, atEOF bool) (int, []byte, error) {
+	switch {
+	case data[i] == '\n':
+	case i+1 < len(data) && data[i+1] == '\n':
+		i++
+	case i+1 == len(data) && !atEOF:
+		// A CR at the end of the buffer may be followed by a LF
+		return i, nil, nil
+	}
+	p.skippingLine = false
+	p.skipped = true
+	return i + 1, data[:0], nil
+}
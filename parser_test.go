@@ -0,0 +1,148 @@
+package sse
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseAll returns the tokens of the stream.
+func parseAll(t testing.TB, stream string) []token {
+	p := newEventParser(strings.NewReader(stream), defaultParserLimits)
+	defer p.release()
+
+	var tokens []token
+	for {
+		tok, err := p.next()
+		if errors.Is(err, io.EOF) {
+			return tokens
+		}
+		require.NoError(t, err)
+		tokens = append(tokens, tok)
+	}
+}
+
+func TestParser(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		stream   string
+		expected []token
+	}{
+		"fields": {
+			stream: "id: 1\nevent: update\ndata: {\"a\": 1}\n\n",
+			expected: []token{
+				{kind: tokenEvent, event: Event{ID: "1", Name: "update", Data: `{"a": 1}`}},
+			},
+		},
+		"multi-line data": {
+			stream:   "data: first\ndata:second\ndata:  third\n\n",
+			expected: []token{{kind: tokenEvent, event: Event{Data: "first\nsecond\n third"}}},
+		},
+		"line endings": {
+			stream: "data: lf\n\ndata: crlf\r\n\r\ndata: cr\r\rdata: mixed\r\n\n",
+			expected: []token{
+				{kind: tokenEvent, event: Event{Data: "lf"}},
+				{kind: tokenEvent, event: Event{Data: "crlf"}},
+				{kind: tokenEvent, event: Event{Data: "cr"}},
+				{kind: tokenEvent, event: Event{Data: "mixed"}},
+			},
+		},
+		"comments and retry": {
+			stream: ": hello\n:keepalive\nretry: 1000\n\nretry: soon\ndata: x\n\n",
+			expected: []token{
+				{kind: tokenComment, comment: "hello"},
+				{kind: tokenComment, comment: "keepalive"},
+				{kind: tokenRetry, retry: 1000},
+				{kind: tokenEvent, event: Event{Data: "x"}},
+			},
+		},
+		"comment of the event": {
+			stream: ": keepalive\n\n: hello\nid: 1\n: world\ndata: x\n\ndata: y\n\n",
+			expected: []token{
+				{kind: tokenComment, comment: "keepalive"},
+				{kind: tokenComment, comment: "hello"},
+				{kind: tokenComment, comment: "world"},
+				{kind: tokenEvent, event: Event{ID: "1", Comment: "world", Data: "x"}},
+				{kind: tokenEvent, event: Event{Data: "y"}},
+			},
+		},
+		"empty id": {
+			stream:   "id\ndata\n\n",
+			expected: []token{{kind: tokenEvent, event: Event{}}},
+		},
+		"invalid line": {
+			stream:   "junk\n",
+			expected: []token{{kind: tokenInvalid, line: "junk"}},
+		},
+		"incomplete event": {
+			stream:   "data: complete\n\ndata: incomplete\n",
+			expected: []token{{kind: tokenEvent, event: Event{Data: "complete"}}},
+		},
+		"incomplete line": {
+			stream: "data: x\n\ndata: no line ending",
+			expected: []token{
+				{kind: tokenEvent, event: Event{Data: "x"}},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, parseAll(t, tc.stream))
+		})
+	}
+}
+
+func TestParserLongLine(t *testing.T) {
+	t.Parallel()
+	data := strings.Repeat("x", 10*parserBufferSize)
+	assert.Equal(t, []token{{kind: tokenEvent, event: Event{Data: data}}}, parseAll(t, "data: "+data+"\n\n"))
+}
+
+func benchmarkParser(b *testing.B, event string) {
+	const events = 1000
+	stream := bytes.Repeat([]byte(event), events)
+	reader := bytes.NewReader(stream)
+
+	b.SetBytes(int64(len(stream)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		reader.Reset(stream)
+		p := newEventParser(reader, defaultParserLimits)
+		for {
+			_, err := p.next()
+			if err != nil {
+				break
+			}
+		}
+		p.release()
+	}
+	b.ReportMetric(float64(events*b.N)/b.Elapsed().Seconds(), "events/s")
+}
+
+func BenchmarkParser(b *testing.B) {
+	b.Run("small token events", func(b *testing.B) {
+		benchmarkParser(b, "event: token\ndata: {\"t\":\"hello\"}\n\n")
+	})
+
+	b.Run("large JSON events", func(b *testing.B) {
+		var choices []string
+		for i := 0; i < 100; i++ {
+			choices = append(choices, fmt.Sprintf(`{"index":%d,"delta":{"role":"assistant","content":"token %d"},"finish_reason":null}`, i, i))
+		}
+		benchmarkParser(b, `id: chatcmpl-1`+"\n"+`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","choices":[`+
+			strings.Join(choices, ",")+"]}\n\n")
+	})
+
+	b.Run("many-line data", func(b *testing.B) {
+		benchmarkParser(b, strings.Repeat("data: line of a multi-line event\n", 50)+"\n")
+	})
+}
@@ -0,0 +1,344 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grafana/sobek"
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/metrics"
+)
+
+// defaultProbeDeadline is the maximum time to wait for the published event by default.
+const defaultProbeDeadline = 10 * time.Second
+
+// ProbeResult is the result returned by sse.probe.
+type ProbeResult struct {
+	Delivered     bool          `json:"delivered"`
+	Delivery      float64       `json:"delivery"`
+	Event         *Event        `json:"event"`
+	PublishStatus int           `json:"publishStatus" js:"publishStatus"`
+	Response      *HTTPResponse `json:"response"`
+	Error         string        `json:"error"`
+}
+
+type probeArgs struct {
+	publishURL     string
+	publishMethod  string
+	publishBody    string
+	publishHeaders http.Header
+	matchFn        sobek.Callable
+	matchData      string
+	deadline       time.Duration
+}
+
+type publishResult struct {
+	status int
+	err    error
+}
+
+// Probe subscribes to the stream, publishes an event once the stream is open and
+// measures the time until the matching event is delivered on the stream.
+func (mi *sse) Probe(urlV sobek.Value, paramsV sobek.Value) (*ProbeResult, error) {
+	ctx := mi.vu.Context()
+	rt := mi.vu.Runtime()
+	state := mi.vu.State()
+	if state == nil {
+		return nil, ErrSSEInInitContext
+	}
+
+	if sobek.IsUndefined(paramsV) || sobek.IsNull(paramsV) {
+		return nil, errors.New("sse.probe() requires publish params")
+	}
+
+	parsedArgs := newConnectArgs(state)
+	if err := parseConnectOptionalArgs(paramsV, rt, parsedArgs); err != nil {
+		return nil, err
+	}
+	probe, err := parseProbeArgs(rt, paramsV, parsedArgs.headers.Get("User-Agent"))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := urlV.Export().([]interface{}); ok {
+		return nil, errors.New("invalid sse.probe() url: a list of urls is not supported by sse.probe()")
+	}
+	url, err := resolveURL(state, urlV, parsedArgs)
+	if err != nil {
+		return nil, err
+	}
+	if err := parsedArgs.auth.checkCredentials(url); err != nil {
+		return nil, fmt.Errorf("invalid sse.probe() auth: %w", err)
+	}
+
+	client, connEndHook, err := mi.open(ctx, state, rt, url, parsedArgs)
+	defer connEndHook()
+	defer client.tracer.end()
+	if err != nil {
+		client.tracer.fail(err)
+		client.pushProbeResult(false, 0)
+		if state.Options.Throw.Bool {
+			return nil, err
+		}
+		return &ProbeResult{Error: err.Error()}, nil
+	}
+
+	result := client.probe(probe)
+	result.Response = client.wrapHTTPResponse("")
+
+	return result, nil
+}
+
+// probe publishes the event and waits for its delivery on the stream.
+// As in Open, all JS code is executed by this thread.
+func (c *Client) probe(args *probeArgs) *ProbeResult {
+	result := &ProbeResult{}
+
+	reader := c.read()
+
+	publishCtx, cancelPublish := context.WithCancel(c.ctx)
+	defer cancelPublish()
+	publishChan := make(chan publishResult, 1)
+	publishStart := time.Now()
+	go c.publish(publishCtx, args, publishChan)
+
+	deadline := time.NewTimer(args.deadline)
+	defer deadline.Stop()
+
+	deliver := func(event Event, received time.Time) {
+		c.recordEvent(event, received)
+		if !result.Delivered && args.matches(c.rt, event) {
+			result.Delivered = true
+			result.Delivery = metrics.D(received.Sub(publishStart))
+			result.Event = &event
+			_ = c.closeWith(closeReasonClient)
+		}
+	}
+
+	for {
+		select {
+		case event := <-reader.events:
+			deliver(event, time.Now())
+
+		case <-reader.queued():
+			// Queued events are received once parsed, regardless of the time they waited in the queue
+			event, dropped, ok := reader.queue.pop()
+			c.pushDropped(dropped, time.Now())
+			if ok {
+				deliver(event, event.parsed)
+			}
+
+		case <-reader.comments:
+			c.recordComment(time.Now())
+
+		case published := <-publishChan:
+			result.PublishStatus = published.status
+			if published.err != nil {
+				result.Error = fmt.Sprintf("publish failed: %s", published.err)
+				_ = c.closeWith(closeReasonError)
+			}
+
+		case readErr := <-reader.errors:
+			c.checkOversized(readErr)
+			if !result.Delivered && result.Error == "" {
+				result.Error = readErr.Error()
+			}
+
+		case violation := <-reader.violations:
+			c.recordViolation(violation)
+
+		case <-deadline.C:
+			result.Error = fmt.Sprintf("event not delivered within %s", args.deadline)
+			_ = c.closeWith(closeReasonTimeout)
+
+		case <-c.ctx.Done():
+			_ = c.closeWith(closeReasonContext)
+
+		case readErr := <-reader.closed:
+			c.checkOversized(readErr)
+			if c.closeReason == "" && result.Error == "" {
+				result.Error = "stream closed before the event was delivered"
+				if readErr != nil {
+					result.Error = readErr.Error()
+				}
+			}
+			c.onReadClose(readErr)
+
+		case <-c.done:
+			c.pushProbeResult(result.Delivered, result.Delivery)
+			return result
+		}
+	}
+}
+
+// publish issues the publish request, its result is sent to the given channel.
+func (c *Client) publish(ctx context.Context, args *probeArgs, publishChan chan<- publishResult) {
+	req, err := http.NewRequestWithContext(ctx, args.publishMethod, args.publishURL, strings.NewReader(args.publishBody))
+	if err != nil {
+		publishChan <- publishResult{err: err}
+		return
+	}
+	req.Header = args.publishHeaders
+
+	client, err := c.newPublishClient(args.publishURL)
+	if err != nil {
+		publishChan <- publishResult{err: err}
+		return
+	}
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		publishChan <- publishResult{err: err}
+		return
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		err = fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	publishChan <- publishResult{status: resp.StatusCode, err: err}
+}
+
+// newPublishClient returns the http client of the publish request. The request runs concurrently
+// with the event loop, so the client shares neither the redirect policy nor the byte counters of the stream.
+func (c *Client) newPublishClient(publishURL string) (*http.Client, error) {
+	tlsConfig, err := c.args.tls.apply(c.state.TLSConfig, publishURL)
+	if err != nil {
+		return nil, err
+	}
+
+	maxRedirects := c.args.redirects
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext:     c.dialer.DialContext,
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+		CheckRedirect: func(_ *http.Request, via []*http.Request) error {
+			if int64(len(via)) > maxRedirects {
+				return http.ErrUseLastResponse
+			}
+			return nil
+		},
+	}
+	if c.args.cookieJar != nil {
+		client.Jar = c.args.cookieJar
+	}
+	return client, nil
+}
+
+// matches returns true if the event is the published one.
+func (p *probeArgs) matches(rt *sobek.Runtime, ev Event) bool {
+	if p.matchFn != nil {
+		v, err := p.matchFn(sobek.Undefined(), rt.ToValue(ev))
+		if err != nil {
+			common.Throw(rt, err)
+		}
+		return v.ToBoolean()
+	}
+	return strings.Contains(ev.Data, p.matchData)
+}
+
+func (c *Client) pushProbeResult(delivered bool, delivery float64) {
+	now := time.Now()
+	samples := []metrics.Sample{
+		{
+			TimeSeries: metrics.TimeSeries{
+				Metric: c.sseMetrics.SSEProbeFailed,
+				Tags:   c.tagsAndMeta.Tags,
+			},
+			Time:     now,
+			Metadata: c.tagsAndMeta.Metadata,
+			Value:    metrics.B(!delivered),
+		},
+	}
+	if delivered {
+		samples = append(samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: c.sseMetrics.SSEProbeDelivery,
+				Tags:   c.tagsAndMeta.Tags,
+			},
+			Time:     now,
+			Metadata: c.tagsAndMeta.Metadata,
+			Value:    delivery,
+		})
+	}
+
+	metrics.PushIfNotDone(c.ctx, c.samplesOutput, metrics.ConnectedSamples{
+		Samples: samples,
+		Tags:    c.tagsAndMeta.Tags,
+		Time:    now,
+	})
+}
+
+func parseProbeArgs(rt *sobek.Runtime, paramsV sobek.Value, userAgent string) (*probeArgs, error) {
+	args := &probeArgs{
+		publishMethod:  http.MethodPost,
+		publishHeaders: make(http.Header),
+		deadline:       defaultProbeDeadline,
+	}
+	args.publishHeaders.Set("User-Agent", userAgent)
+
+	params := paramsV.ToObject(rt)
+	for _, k := range params.Keys() {
+		v := params.Get(k)
+		if sobek.IsUndefined(v) || sobek.IsNull(v) {
+			continue
+		}
+		switch k {
+		case "publish":
+			parseProbePublish(rt, v, args)
+		case "match":
+			if matchFn, ok := sobek.AssertFunction(v); ok {
+				args.matchFn = matchFn
+			} else {
+				args.matchData = v.String()
+			}
+		case "deadline":
+			deadline, err := time.ParseDuration(v.String())
+			if err != nil {
+				return nil, fmt.Errorf("invalid sse.probe() deadline: %w", err)
+			}
+			args.deadline = deadline
+		// The probe stops once the event is delivered, the params of long-lived streams do not apply
+		case "heartbeatTimeout", "closeOnHeartbeatTimeout", "reconnect", "batch", "flushInterval":
+			return nil, fmt.Errorf("invalid sse.probe() %s: not supported by sse.probe()", k)
+		}
+	}
+
+	if args.publishURL == "" {
+		return nil, errors.New("sse.probe() requires a publish url")
+	}
+
+	return args, nil
+}
+
+func parseProbePublish(rt *sobek.Runtime, publishV sobek.Value, args *probeArgs) {
+	publish := publishV.ToObject(rt)
+	for _, k := range publish.Keys() {
+		v := publish.Get(k)
+		if sobek.IsUndefined(v) || sobek.IsNull(v) {
+			continue
+		}
+		switch k {
+		case "url":
+			args.publishURL = v.String()
+		case "method":
+			args.publishMethod = strings.TrimSpace(v.String())
+		case "body":
+			args.publishBody = v.String()
+		case "headers":
+			headersObj := v.ToObject(rt)
+			for _, key := range headersObj.Keys() {
+				args.publishHeaders.Set(key, headersObj.Get(key).String())
+			}
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package sse
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/metrics"
+)
+
+// pubSubHandlers returns a subscribe handler streaming every message posted to the publish handler.
+func pubSubHandlers(t testing.TB) (http.Handler, http.Handler) {
+	messages := make(chan string, 10)
+
+	subscribe := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush() //nolint:forcetypeassert
+
+		_, err := w.Write([]byte("data: unrelated\n\n"))
+		require.NoError(t, err)
+		w.(http.Flusher).Flush() //nolint:forcetypeassert
+
+		for {
+			select {
+			case msg := <-messages:
+				time.Sleep(20 * time.Millisecond)
+				_, err = w.Write([]byte("data: " + msg + "\n\n"))
+				require.NoError(t, err)
+				w.(http.Flusher).Flush() //nolint:forcetypeassert
+			case <-req.Context().Done():
+				return
+			}
+		}
+	})
+
+	publish := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		messages <- string(body)
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	return subscribe, publish
+}
+
+func TestProbe(t *testing.T) {
+	t.Parallel()
+
+	t.Run("delivered", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		subscribe, publish := pubSubHandlers(t)
+		test.tb.Mux.Handle("/sse-subscribe", subscribe)
+		test.tb.Mux.Handle("/publish", publish)
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var res = sse.probe("HTTPBIN_IP_URL/sse-subscribe", {
+			publish: {url: "HTTPBIN_IP_URL/publish", body: '{"probe": 42}'},
+			match: function(event) { return event.data.startsWith("{") && JSON.parse(event.data).probe === 42 },
+			deadline: "5s",
+		});
+		if (!res.delivered) {
+			throw new Error("event not delivered: " + res.error);
+		}
+		if (res.delivery < 20) {
+			throw new Error("unexpected delivery time: " + res.delivery);
+		}
+		if (res.publishStatus !== 202) {
+			throw new Error("unexpected publish status: " + res.publishStatus);
+		}
+		if (res.event.data !== '{"probe": 42}') {
+			throw new Error("unexpected event data: " + res.event.data);
+		}
+		if (res.response.status !== 200) {
+			throw new Error("unexpected status: " + res.response.status);
+		}
+		`))
+		require.NoError(t, err)
+
+		samplesBuf := metrics.GetBufferedSamples(test.samples)
+		url := sr("HTTPBIN_IP_URL/sse-subscribe")
+		assertMetricEmittedCount(t, MetricProbeDeliveryName, samplesBuf, url, 1)
+		assertMetricEmittedCount(t, MetricProbeFailedName, samplesBuf, url, 1)
+		assertSseCount(t, samplesBuf, url, 2)
+	})
+
+	t.Run("delivered from the queue", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		subscribe, publish := pubSubHandlers(t)
+		test.tb.Mux.Handle("/sse-subscribe", subscribe)
+		test.tb.Mux.Handle("/publish", publish)
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var res = sse.probe("HTTPBIN_IP_URL/sse-subscribe", {
+			publish: {url: "HTTPBIN_IP_URL/publish", body: 'queued'},
+			match: function(event) { return event.data === "queued" },
+			queue: {size: 10},
+			deadline: "2s",
+		});
+		if (!res.delivered || res.event.data !== "queued") {
+			throw new Error("event not delivered: " + res.error);
+		}
+		`))
+		require.NoError(t, err)
+
+		samplesBuf := metrics.GetBufferedSamples(test.samples)
+		url := sr("HTTPBIN_IP_URL/sse-subscribe")
+		assertMetricEmittedCount(t, MetricProbeDeliveryName, samplesBuf, url, 1)
+		assertSseCount(t, samplesBuf, url, 2)
+	})
+
+	t.Run("not delivered", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		subscribe, publish := pubSubHandlers(t)
+		test.tb.Mux.Handle("/sse-subscribe", subscribe)
+		test.tb.Mux.Handle("/publish", publish)
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var res = sse.probe("HTTPBIN_IP_URL/sse-subscribe", {
+			publish: {url: "HTTPBIN_IP_URL/publish", body: 'hello'},
+			match: 'never',
+			deadline: "200ms",
+		});
+		if (res.delivered) {
+			throw new Error("unexpected delivery");
+		}
+		if (res.error !== "event not delivered within 200ms") {
+			throw new Error("unexpected error: " + res.error);
+		}
+		`))
+		require.NoError(t, err)
+
+		samplesBuf := metrics.GetBufferedSamples(test.samples)
+		url := sr("HTTPBIN_IP_URL/sse-subscribe")
+		assertMetricEmittedCount(t, MetricProbeDeliveryName, samplesBuf, url, 0)
+		for _, sampleContainer := range samplesBuf {
+			for _, sample := range sampleContainer.GetSamples() {
+				if sample.Metric.Name == MetricProbeFailedName {
+					assert.Equal(t, 1.0, sample.Value)
+				}
+			}
+		}
+	})
+
+	t.Run("redirected publish", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		subscribe, publish := pubSubHandlers(t)
+		test.tb.Mux.Handle("/sse-subscribe", subscribe)
+		test.tb.Mux.Handle("/publish", publish)
+		test.tb.Mux.Handle("/publish-moved", http.RedirectHandler("/publish", http.StatusTemporaryRedirect))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var res = sse.probe("HTTPBIN_IP_URL/sse-subscribe", {
+			publish: {url: "HTTPBIN_IP_URL/publish-moved", body: 'moved'},
+			match: 'moved',
+			deadline: "5s",
+		});
+		if (!res.delivered || res.publishStatus !== 202) {
+			throw new Error("event not delivered: " + res.error);
+		}
+		`))
+		require.NoError(t, err)
+
+		// The redirection of the publish request is not a hop of the stream
+		var httpReqs int
+		for _, sampleContainer := range metrics.GetBufferedSamples(test.samples) {
+			for _, sample := range sampleContainer.GetSamples() {
+				if sample.Metric.Name == metrics.HTTPReqsName {
+					httpReqs++
+					url, _ := sample.Tags.Get("url")
+					assert.Equal(t, sr("HTTPBIN_IP_URL/sse-subscribe"), url)
+				}
+			}
+		}
+		assert.Equal(t, 1, httpReqs)
+	})
+
+	t.Run("missing publish url", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		sse.probe("HTTPBIN_IP_URL/sse", {match: 'pong'});
+		`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "sse.probe() requires a publish url")
+	})
+
+	t.Run("unsupported params", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		for param, value := range map[string]string{
+			"heartbeatTimeout":        `"1s"`,
+			"closeOnHeartbeatTimeout": `true, heartbeatTimeout: "1s"`,
+			"reconnect":               `{}`,
+			"batch":                   `{}`,
+			"flushInterval":           `"1s"`,
+		} {
+			_, err := test.VU.Runtime().RunString(sr(`
+			sse.probe("HTTPBIN_IP_URL/sse", {publish: {url: "HTTPBIN_IP_URL/publish"}, match: 'pong', ` + param + `: ` + value + `});
+			`))
+			require.ErrorContains(t, err, "invalid sse.probe() "+param+": not supported by sse.probe()")
+		}
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		sse.probe(["HTTPBIN_IP_URL/sse", "HTTPBIN_IP_URL/sse"], {publish: {url: "HTTPBIN_IP_URL/publish"}, match: 'pong'});
+		`))
+		require.ErrorContains(t, err, "invalid sse.probe() url: a list of urls is not supported by sse.probe()")
+	})
+}
@@ -0,0 +1,184 @@
+package sse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/sobek"
+	"go.k6.io/k6/metrics"
+)
+
+// Overflow policies of the queue param.
+const (
+	queueBlock      = "block"
+	queueDropOldest = "drop_oldest"
+	queueDropNewest = "drop_newest"
+	queueCoalesce   = "coalesce"
+)
+
+type queueOptions struct {
+	size   int
+	policy string
+}
+
+// eventQueue is a bounded queue of the events read and not yet dispatched, so the stream is read
+// at the pace of the server rather than of the handlers. Once full, the policy either blocks the reader,
+// drops the oldest or the newest event, or coalesces the new event with a queued one of the same name.
+type eventQueue struct {
+	opts *queueOptions
+
+	mu      sync.Mutex
+	events  []Event
+	dropped int64
+
+	// ready is signaled once events are queued, dequeued once they are popped
+	ready    chan struct{}
+	dequeued chan struct{}
+}
+
+func newEventQueue(opts *queueOptions) *eventQueue {
+	if opts == nil {
+		return nil
+	}
+	return &eventQueue{
+		opts:     opts,
+		events:   make([]Event, 0, opts.size),
+		ready:    make(chan struct{}, 1),
+		dequeued: make(chan struct{}, 1),
+	}
+}
+
+// push queues the event according to the overflow policy, false if done while blocked.
+func (q *eventQueue) push(ev Event, done chan struct{}) bool {
+	q.mu.Lock()
+	for q.opts.policy == queueBlock && len(q.events) >= q.opts.size {
+		q.mu.Unlock()
+		select {
+		case <-q.dequeued:
+		case <-done:
+			return false
+		}
+		q.mu.Lock()
+	}
+
+	switch {
+	case len(q.events) < q.opts.size:
+		q.events = append(q.events, ev)
+	case q.opts.policy == queueDropNewest:
+		q.dropped++
+	case q.opts.policy == queueCoalesce && q.coalesce(ev):
+		q.dropped++
+	default:
+		// drop_oldest, or coalesce without a queued event of the same name
+		copy(q.events, q.events[1:])
+		q.events[len(q.events)-1] = ev
+		q.dropped++
+	}
+	q.mu.Unlock()
+
+	signal(q.ready)
+	return true
+}
+
+// coalesce replaces the latest queued event of the same name, false if there is none.
+func (q *eventQueue) coalesce(ev Event) bool {
+	for i := len(q.events) - 1; i >= 0; i-- {
+		if q.events[i].Name == ev.Name {
+			q.events[i] = ev
+			return true
+		}
+	}
+	return false
+}
+
+// pop dequeues the oldest event and returns the number of events dropped since the previous pop.
+func (q *eventQueue) pop() (Event, int64, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	dropped := q.dropped
+	q.dropped = 0
+	if len(q.events) == 0 {
+		return Event{}, dropped, false
+	}
+	ev := q.events[0]
+	q.events = q.events[1:]
+	if len(q.events) > 0 {
+		signal(q.ready)
+	}
+	signal(q.dequeued)
+	return ev, dropped, true
+}
+
+// drain waits until the queued events are dispatched, false if done before.
+func (q *eventQueue) drain(done chan struct{}) bool {
+	for {
+		q.mu.Lock()
+		empty := len(q.events) == 0
+		q.mu.Unlock()
+		if empty {
+			return true
+		}
+		select {
+		case <-q.dequeued:
+		case <-done:
+			return false
+		}
+	}
+}
+
+// signal notifies a channel of capacity 1 without blocking.
+func signal(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+// pushDropped pushes the number of events dropped by the queue.
+func (c *Client) pushDropped(dropped int64, t time.Time) {
+	if dropped == 0 {
+		return
+	}
+	metrics.PushIfNotDone(c.ctx, c.samplesOutput, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: c.sseMetrics.SSEEventsDropped,
+			Tags:   c.tagsAndMeta.Tags,
+		},
+		Time:     t,
+		Metadata: c.tagsAndMeta.Metadata,
+		Value:    float64(dropped),
+	})
+}
+
+func parseQueueOptions(rt *sobek.Runtime, queueV sobek.Value) (*queueOptions, error) {
+	opts := &queueOptions{policy: queueBlock}
+
+	queueObj := queueV.ToObject(rt)
+	for _, k := range queueObj.Keys() {
+		v := queueObj.Get(k)
+		if sobek.IsUndefined(v) || sobek.IsNull(v) {
+			continue
+		}
+		switch k {
+		case "size":
+			opts.size = int(v.ToInteger())
+		case "policy":
+			switch policy := strings.TrimSpace(v.String()); policy {
+			case queueBlock, queueDropOldest, queueDropNewest, queueCoalesce:
+				opts.policy = policy
+			default:
+				return nil, fmt.Errorf("unknown policy %q", policy)
+			}
+		}
+	}
+
+	if opts.size <= 0 {
+		return nil, errors.New("invalid size: must be positive")
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,140 @@
+package sse
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/metrics"
+)
+
+// sseBurstHandler writes the events at once, their data is their index.
+func sseBurstHandler(events int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < events; i++ {
+			_, _ = fmt.Fprintf(w, "data: %d\n\n", i)
+		}
+	})
+}
+
+func TestEventQueue(t *testing.T) {
+	t.Parallel()
+
+	pushAll := func(q *eventQueue, events ...Event) {
+		for _, ev := range events {
+			require.True(t, q.push(ev, nil))
+		}
+	}
+	popAll := func(q *eventQueue) ([]string, int64) {
+		var data []string
+		var dropped int64
+		for {
+			ev, d, ok := q.pop()
+			dropped += d
+			if !ok {
+				return data, dropped
+			}
+			data = append(data, ev.Data)
+		}
+	}
+	a, b, c := Event{Name: "x", Data: "a"}, Event{Name: "y", Data: "b"}, Event{Name: "x", Data: "c"}
+
+	for policy, expected := range map[string][]string{
+		queueDropOldest: {"b", "c"},
+		queueDropNewest: {"a", "b"},
+		queueCoalesce:   {"c", "b"},
+	} {
+		q := newEventQueue(&queueOptions{size: 2, policy: policy})
+		pushAll(q, a, b, c)
+		data, dropped := popAll(q)
+		assert.Equal(t, expected, data, policy)
+		assert.Equal(t, int64(1), dropped, policy)
+	}
+
+	t.Run("coalesce without the same name", func(t *testing.T) {
+		t.Parallel()
+		q := newEventQueue(&queueOptions{size: 2, policy: queueCoalesce})
+		pushAll(q, a, b, Event{Name: "z", Data: "d"})
+		data, dropped := popAll(q)
+		assert.Equal(t, []string{"b", "d"}, data)
+		assert.Equal(t, int64(1), dropped)
+	})
+
+	t.Run("block", func(t *testing.T) {
+		t.Parallel()
+		q := newEventQueue(&queueOptions{size: 1, policy: queueBlock})
+		pushAll(q, a)
+
+		pushed := make(chan bool)
+		go func() { pushed <- q.push(b, nil) }()
+		select {
+		case <-pushed:
+			t.Fatal("the reader is not blocked once the queue is full")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		ev, _, _ := q.pop()
+		assert.Equal(t, a, ev)
+		assert.True(t, <-pushed)
+
+		done := make(chan struct{})
+		close(done)
+		assert.False(t, q.push(c, done), "a blocked reader stops once done")
+	})
+}
+
+func TestQueue(t *testing.T) {
+	t.Parallel()
+
+	t.Run("drop oldest", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-burst", sseBurstHandler(50))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var events = []
+		sse.open("HTTPBIN_IP_URL/sse-burst", {queue: {size: 2, policy: "drop_oldest"}}, function(client){
+			client.on("event", function(event) {
+				events.push(event.data)
+				var start = Date.now()
+				while (Date.now() - start < 10) {}
+			})
+		})
+		if (events[events.length - 1] != "49") {
+			throw new Error("the last event is not dispatched: " + events.join())
+		}
+		`))
+		require.NoError(t, err)
+
+		var received, dropped float64
+		for _, sampleContainer := range metrics.GetBufferedSamples(test.samples) {
+			for _, sample := range sampleContainer.GetSamples() {
+				switch sample.Metric.Name {
+				case MetricEventName:
+					received += sample.Value
+				case MetricEventsDroppedName:
+					dropped += sample.Value
+				}
+			}
+		}
+		assert.Positive(t, dropped)
+		assert.Equal(t, float64(50), received+dropped)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		_, err := test.VU.Runtime().RunString(sr(`sse.open("HTTPBIN_IP_URL/sse", {queue: {policy: "drop_oldest"}}, function(client){});`))
+		require.ErrorContains(t, err, "invalid sse.open() queue: invalid size: must be positive")
+
+		_, err = test.VU.Runtime().RunString(sr(`sse.open("HTTPBIN_IP_URL/sse", {queue: {size: 1, policy: "sample"}}, function(client){});`))
+		require.ErrorContains(t, err, `invalid sse.open() queue: unknown policy "sample"`)
+	})
+}
@@ -0,0 +1,230 @@
+package sse
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/grafana/sobek"
+	"go.k6.io/k6/metrics"
+)
+
+// Backoff policies of the reconnect param.
+const (
+	reconnectConstant    = "constant"
+	reconnectLinear      = "linear"
+	reconnectExponential = "exponential"
+)
+
+// Jitters applied to the backoff delay.
+const (
+	jitterNone         = "none"
+	jitterFull         = "full"
+	jitterDecorrelated = "decorrelated"
+)
+
+// minReconnectDelay is the lowest delay waited before reconnecting, so a zero delay or
+// retry hint does not reconnect in a tight loop.
+const minReconnectDelay = time.Millisecond
+
+type reconnectOptions struct {
+	policy         string
+	delay          time.Duration
+	maxDelay       time.Duration
+	jitter         string
+	maxAttempts    int64
+	maxElapsedTime time.Duration
+}
+
+// ReconnectEvent is passed to the reconnecting handlers before waiting to reconnect.
+type ReconnectEvent struct {
+	Attempt int64   `json:"attempt"`
+	Delay   float64 `json:"delay"`
+	Reason  string  `json:"reason"`
+}
+
+// reconnector computes the delays of the successive reconnection attempts.
+// A nil reconnector never reconnects.
+type reconnector struct {
+	opts     *reconnectOptions
+	attempt  int64
+	started  time.Time
+	previous time.Duration
+}
+
+func newReconnector(opts *reconnectOptions) *reconnector {
+	if opts == nil {
+		return nil
+	}
+	return &reconnector{opts: opts}
+}
+
+// next returns the delay before the next attempt, false once the attempts are exhausted.
+// The retry hint of the server in milliseconds, if any, replaces the base delay as with EventSource.
+func (r *reconnector) next(reason string, retryHint int64) (time.Duration, bool) {
+	if r == nil || !isReconnectable(reason) {
+		return 0, false
+	}
+	if r.attempt == 0 {
+		r.started = time.Now()
+	}
+	if r.opts.maxAttempts > 0 && r.attempt >= r.opts.maxAttempts {
+		return 0, false
+	}
+
+	base := r.opts.delay
+	if retryHint >= 0 {
+		// The hint is bounded before its conversion, so a huge hint does not overflow
+		base = time.Duration(min(retryHint, r.opts.maxDelay.Milliseconds())) * time.Millisecond
+	}
+	base = max(base, minReconnectDelay)
+
+	r.attempt++
+	delay := base
+	switch r.opts.policy {
+	case reconnectLinear:
+		delay = base * time.Duration(r.attempt)
+	case reconnectExponential:
+		for i := int64(1); i < r.attempt && delay < r.opts.maxDelay; i++ {
+			delay *= 2
+		}
+	}
+
+	switch r.opts.jitter {
+	case jitterFull:
+		delay = randDuration(0, min(delay, r.opts.maxDelay))
+	case jitterDecorrelated:
+		// The delay grows randomly from the previous one, regardless of the policy
+		previous := r.previous
+		if previous == 0 {
+			previous = base
+		}
+		delay = randDuration(base, 3*previous)
+	}
+	delay = max(min(delay, r.opts.maxDelay), minReconnectDelay)
+	r.previous = delay
+
+	if r.opts.maxElapsedTime > 0 && time.Since(r.started)+delay > r.opts.maxElapsedTime {
+		return 0, false
+	}
+
+	return delay, true
+}
+
+// reset restarts the attempts once reconnected.
+func (r *reconnector) reset() {
+	if r == nil {
+		return
+	}
+	r.attempt = 0
+	r.previous = 0
+}
+
+// isReconnectable returns true if a stream closed for the reason is reconnected,
+// streams closed by the client, the context or a timeout are not.
+func isReconnectable(reason string) bool {
+	return reason == closeReasonServer || reason == closeReasonError || reason == closeReasonHeartbeat
+}
+
+func randDuration(low, high time.Duration) time.Duration {
+	if high <= low {
+		return low
+	}
+	return low + rand.N(high-low) //nolint:gosec // jitter does not need a secure random
+}
+
+// pushReconnect pushes the metrics of a reconnection attempt.
+func (c *Client) pushReconnect(delay time.Duration) {
+	now := time.Now()
+	metrics.PushIfNotDone(c.ctx, c.samplesOutput, metrics.ConnectedSamples{
+		Samples: []metrics.Sample{
+			{
+				TimeSeries: metrics.TimeSeries{
+					Metric: c.sseMetrics.SSEReconnects,
+					Tags:   c.tagsAndMeta.Tags,
+				},
+				Time:     now,
+				Metadata: c.tagsAndMeta.Metadata,
+				Value:    1,
+			},
+			{
+				TimeSeries: metrics.TimeSeries{
+					Metric: c.sseMetrics.SSEReconnectDelay,
+					Tags:   c.tagsAndMeta.Tags,
+				},
+				Time:     now,
+				Metadata: c.tagsAndMeta.Metadata,
+				Value:    metrics.D(delay),
+			},
+		},
+		Tags: c.tagsAndMeta.Tags,
+		Time: now,
+	})
+}
+
+func parseReconnectOptions(rt *sobek.Runtime, reconnectV sobek.Value) (*reconnectOptions, error) {
+	opts := &reconnectOptions{
+		policy:   reconnectExponential,
+		delay:    time.Second,
+		maxDelay: 30 * time.Second,
+		jitter:   jitterNone,
+	}
+
+	// reconnect: true uses the defaults
+	if _, ok := reconnectV.Export().(bool); ok {
+		return opts, nil
+	}
+
+	reconnectObj := reconnectV.ToObject(rt)
+	for _, k := range reconnectObj.Keys() {
+		v := reconnectObj.Get(k)
+		if sobek.IsUndefined(v) || sobek.IsNull(v) {
+			continue
+		}
+		switch k {
+		case "policy":
+			switch policy := strings.TrimSpace(v.String()); policy {
+			case reconnectConstant, reconnectLinear, reconnectExponential:
+				opts.policy = policy
+			default:
+				return nil, fmt.Errorf("unknown policy %q", policy)
+			}
+		case "jitter":
+			switch jitter := strings.TrimSpace(v.String()); jitter {
+			case jitterNone, jitterFull, jitterDecorrelated:
+				opts.jitter = jitter
+			default:
+				return nil, fmt.Errorf("unknown jitter %q", jitter)
+			}
+		case "delay", "maxDelay", "maxElapsedTime":
+			d, err := time.ParseDuration(v.String())
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", k, err)
+			}
+			if d < 0 {
+				return nil, fmt.Errorf("invalid %s: must be positive", k)
+			}
+			switch k {
+			case "delay":
+				opts.delay = d
+			case "maxDelay":
+				opts.maxDelay = d
+			default:
+				opts.maxElapsedTime = d
+			}
+		case "maxAttempts":
+			opts.maxAttempts = v.ToInteger()
+			if opts.maxAttempts < 0 {
+				return nil, errors.New("invalid maxAttempts: must be positive")
+			}
+		}
+	}
+
+	if opts.maxDelay < opts.delay {
+		return nil, fmt.Errorf("maxDelay %s is lower than delay %s", opts.maxDelay, opts.delay)
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,231 @@
+package sse
+
+import (
+	"math"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/metrics"
+)
+
+func TestReconnector(t *testing.T) {
+	t.Parallel()
+
+	delays := func(r *reconnector, n int) []time.Duration {
+		var d []time.Duration
+		for i := 0; i < n; i++ {
+			delay, ok := r.next(closeReasonServer, -1)
+			if !ok {
+				break
+			}
+			d = append(d, delay)
+		}
+		return d
+	}
+
+	t.Run("policies", func(t *testing.T) {
+		t.Parallel()
+		for policy, expected := range map[string][]time.Duration{
+			reconnectConstant:    {time.Second, time.Second, time.Second, time.Second},
+			reconnectLinear:      {time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second},
+			reconnectExponential: {time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second},
+		} {
+			r := newReconnector(&reconnectOptions{policy: policy, delay: time.Second, maxDelay: 5 * time.Second, jitter: jitterNone})
+			assert.Equal(t, expected, delays(r, 4), policy)
+		}
+	})
+
+	t.Run("jitters", func(t *testing.T) {
+		t.Parallel()
+		r := newReconnector(&reconnectOptions{policy: reconnectExponential, delay: time.Second, maxDelay: 5 * time.Second, jitter: jitterFull})
+		for i, delay := range delays(r, 10) {
+			assert.GreaterOrEqual(t, delay, time.Duration(0))
+			assert.LessOrEqual(t, delay, min(time.Second<<i, 5*time.Second))
+		}
+
+		r = newReconnector(&reconnectOptions{policy: reconnectConstant, delay: time.Second, maxDelay: 5 * time.Second, jitter: jitterDecorrelated})
+		for _, delay := range delays(r, 10) {
+			assert.GreaterOrEqual(t, delay, time.Second)
+			assert.LessOrEqual(t, delay, 5*time.Second)
+		}
+	})
+
+	t.Run("retry hint and reset", func(t *testing.T) {
+		t.Parallel()
+		r := newReconnector(&reconnectOptions{policy: reconnectLinear, delay: time.Second, maxDelay: time.Minute, jitter: jitterNone})
+		delay, ok := r.next(closeReasonError, 100)
+		require.True(t, ok)
+		assert.Equal(t, 100*time.Millisecond, delay)
+		delay, _ = r.next(closeReasonError, 100)
+		assert.Equal(t, 200*time.Millisecond, delay)
+
+		r.reset()
+		delay, _ = r.next(closeReasonError, -1)
+		assert.Equal(t, time.Second, delay)
+	})
+
+	t.Run("bounded retry hint", func(t *testing.T) {
+		t.Parallel()
+		r := newReconnector(&reconnectOptions{policy: reconnectExponential, delay: time.Second, maxDelay: time.Minute, jitter: jitterNone})
+		delay, ok := r.next(closeReasonServer, math.MaxInt64)
+		require.True(t, ok)
+		assert.Equal(t, time.Minute, delay, "the hint does not overflow")
+
+		r.reset()
+		delay, _ = r.next(closeReasonServer, 0)
+		assert.Equal(t, minReconnectDelay, delay, "a zero hint does not reconnect in a tight loop")
+
+		r = newReconnector(&reconnectOptions{policy: reconnectExponential, delay: 0, maxDelay: 0, jitter: jitterNone})
+		delay, _ = r.next(closeReasonServer, -1)
+		assert.Equal(t, minReconnectDelay, delay)
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		t.Parallel()
+		r := newReconnector(&reconnectOptions{policy: reconnectConstant, delay: time.Millisecond, maxDelay: time.Millisecond, maxAttempts: 3})
+		assert.Len(t, delays(r, 10), 3)
+
+		r = newReconnector(&reconnectOptions{policy: reconnectConstant, delay: time.Second, maxDelay: time.Second, maxElapsedTime: 500 * time.Millisecond})
+		assert.Empty(t, delays(r, 10))
+
+		r = newReconnector(&reconnectOptions{policy: reconnectConstant, delay: time.Second, maxDelay: time.Second})
+		for _, reason := range []string{closeReasonClient, closeReasonContext, closeReasonTimeout} {
+			_, ok := r.next(reason, -1)
+			assert.False(t, ok, reason)
+		}
+
+		_, ok := newReconnector(nil).next(closeReasonServer, -1)
+		assert.False(t, ok)
+	})
+}
+
+func TestReconnect(t *testing.T) {
+	t.Parallel()
+
+	t.Run("resumes the stream", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		var connections atomic.Int64
+		test.tb.Mux.HandleFunc("/sse-reconnect", func(w http.ResponseWriter, req *http.Request) {
+			switch connections.Add(1) {
+			case 1:
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = w.Write([]byte("retry: 10\nid: 1\ndata: first\n\n"))
+			case 2:
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = w.Write([]byte("data: " + req.Header.Get("Last-Event-ID") + "\n\n"))
+			default:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		})
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var opened = 0, events = [], attempts = [], errors = 0
+		var response = sse.open("HTTPBIN_IP_URL/sse-reconnect", {
+			reconnect: {policy: "constant", delay: "1s", maxAttempts: 2},
+		}, function(client){
+			client.on("open", function() { opened++ })
+			client.on("event", function(event) { events.push(event.data) })
+			client.on("reconnecting", function(event) { attempts.push(event.attempt + ":" + event.delay + ":" + event.reason) })
+			client.on("error", function() { errors++ })
+		})
+		if (opened != 2) {
+			throw new Error("unexpected opened: " + opened)
+		}
+		if (events.join() != "first,1") {
+			throw new Error("unexpected events: " + events.join())
+		}
+		if (attempts.join() != "1:10:server,1:10:server,2:10:error") {
+			throw new Error("unexpected reconnecting: " + attempts.join())
+		}
+		if (errors != 2) {
+			throw new Error("unexpected errors: " + errors)
+		}
+		if (response.status != 503) {
+			throw new Error("unexpected status: " + response.status)
+		}
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), connections.Load())
+
+		var reconnects, reconnectDelays []float64
+		for _, sampleContainer := range metrics.GetBufferedSamples(test.samples) {
+			for _, sample := range sampleContainer.GetSamples() {
+				switch sample.Metric.Name {
+				case MetricReconnectsName:
+					reconnects = append(reconnects, sample.Value)
+				case MetricReconnectDelayName:
+					reconnectDelays = append(reconnectDelays, sample.Value)
+				}
+			}
+		}
+		assert.Equal(t, []float64{1, 1, 1}, reconnects)
+		assert.Equal(t, []float64{10, 10, 10}, reconnectDelays)
+	})
+
+	t.Run("closed by the client", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var attempts = 0
+		sse.open("HTTPBIN_IP_URL/sse", {reconnect: {delay: "10ms"}}, function(client){
+			client.on("reconnecting", function() {
+				attempts++
+				client.close()
+			})
+		})
+		if (attempts != 1) {
+			throw new Error("unexpected reconnecting: " + attempts)
+		}
+		`))
+		require.NoError(t, err)
+	})
+
+	t.Run("close event of the whole stream", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-burst", sseBurstHandler(1))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var events = 0, closeEvent
+		sse.open("HTTPBIN_IP_URL/sse-burst", {reconnect: {policy: "constant", delay: "100ms"}}, function(client){
+			client.on("event", function() {
+				if (++events == 2) {
+					client.close()
+				}
+			})
+			client.on("close", function(e) { closeEvent = e })
+		})
+		if (closeEvent.eventsReceived != 2 || closeEvent.duration < 100) {
+			throw new Error("unexpected close event: " + JSON.stringify(closeEvent))
+		}
+		`))
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		for script, expected := range map[string]string{
+			`{reconnect: {policy: "random"}}`:            `invalid sse.open() reconnect: unknown policy "random"`,
+			`{reconnect: {jitter: "half"}}`:              `invalid sse.open() reconnect: unknown jitter "half"`,
+			`{reconnect: {delay: "1m", maxDelay: "1s"}}`: "invalid sse.open() reconnect: maxDelay 1s is lower than delay 1m0s",
+			`{reconnect: {maxAttempts: -1}}`:             "invalid sse.open() reconnect: invalid maxAttempts: must be positive",
+			`{reconnect: {maxElapsedTime: "forever"}}`:   "invalid sse.open() reconnect: invalid maxElapsedTime",
+		} {
+			_, err := test.VU.Runtime().RunString(sr(`sse.open("HTTPBIN_IP_URL/sse", ` + script + `, function(client){});`))
+			require.ErrorContains(t, err, expected, script)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package sse
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.k6.io/k6/lib/netext/httpext"
+	"go.k6.io/k6/metrics"
+)
+
+// defaultMaxRedirects is the number of redirects followed when neither the redirects param
+// nor the maxRedirects option is set, as with the Go http client.
+const defaultMaxRedirects = 10
+
+// checkRedirect returns the redirect policy of the http client. The last redirection is returned
+// once the max redirects is reached, otherwise the metrics of each hop followed are pushed.
+func (c *Client) checkRedirect(maxRedirects int64, enabledTags *metrics.SystemTagSet) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if int64(len(via)) > maxRedirects {
+			return http.ErrUseLastResponse
+		}
+		c.redirects++
+		c.pushRedirectMetrics(via[len(via)-1].URL, req.Response.StatusCode, enabledTags)
+		return nil
+	}
+}
+
+// pushRedirectMetrics pushes the http_reqs and http_req_duration of a redirection,
+// tagged with its own url and status.
+func (c *Client) pushRedirectMetrics(u *url.URL, status int, enabledTags *metrics.SystemTagSet) {
+	end := time.Now()
+	duration := metrics.D(end.Sub(c.hopStart))
+	c.hopStart = end
+
+	hopTagsAndMeta := metrics.TagsAndMeta{Tags: c.tagsAndMeta.Tags, Metadata: c.tagsAndMeta.Metadata}
+	if !c.urlGrouped {
+		setURLTags(&hopTagsAndMeta, enabledTags, u)
+	}
+	hopTagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagStatus, strconv.Itoa(status))
+
+	metrics.PushIfNotDone(c.ctx, c.samplesOutput, metrics.ConnectedSamples{
+		Samples: []metrics.Sample{
+			{
+				TimeSeries: metrics.TimeSeries{
+					Metric: c.builtinMetrics.HTTPReqs,
+					Tags:   hopTagsAndMeta.Tags,
+				},
+				Time:     end,
+				Metadata: hopTagsAndMeta.Metadata,
+				Value:    1,
+			},
+			{
+				TimeSeries: metrics.TimeSeries{
+					Metric: c.builtinMetrics.HTTPReqDuration,
+					Tags:   hopTagsAndMeta.Tags,
+				},
+				Time:     end,
+				Metadata: hopTagsAndMeta.Metadata,
+				Value:    duration,
+			},
+		},
+		Tags: hopTagsAndMeta.Tags,
+		Time: end,
+	})
+}
+
+// setURLTags sets the name and url tags to the url without its credentials.
+func setURLTags(tagsAndMeta *metrics.TagsAndMeta, enabledTags *metrics.SystemTagSet, u *url.URL) {
+	cleanURL, err := httpext.NewURL(u.String(), u.String())
+	if err != nil {
+		return
+	}
+	tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagName, cleanURL.Clean())
+	tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagURL, cleanURL.Clean())
+}
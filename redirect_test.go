@@ -0,0 +1,128 @@
+package sse
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/metrics"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestRedirects(t *testing.T) {
+	t.Parallel()
+
+	registerRedirects := func(test testState) {
+		test.tb.Mux.Handle("/sse-gateway", http.RedirectHandler("/sse-region", http.StatusFound))
+		test.tb.Mux.Handle("/sse-region", http.RedirectHandler("/sse", http.StatusFound))
+		test.tb.Mux.Handle("/sse-upload", http.RedirectHandler("/sse-echo-body", http.StatusTemporaryRedirect))
+		test.tb.Mux.Handle("/sse-echo-body", sseEchoBodyHandler(t))
+	}
+
+	t.Run("hops are recorded", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		registerRedirects(test)
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var events = 0;
+		var res = sse.open("HTTPBIN_IP_URL/sse-gateway", function(client){
+			client.on("event", function(event) {
+				events++;
+			});
+		});
+		if (res.status !== 200 || events !== 2) {
+			throw new Error("unexpected response: " + res.status + " " + events);
+		}
+		if (res.url !== "HTTPBIN_IP_URL/sse") {
+			throw new Error("unexpected url: " + res.url);
+		}
+		`))
+		require.NoError(t, err)
+
+		samplesBuf := metrics.GetBufferedSamples(test.samples)
+		reqs := map[string]string{}
+		for _, sampleContainer := range samplesBuf {
+			for _, sample := range sampleContainer.GetSamples() {
+				if sample.Metric.Name == metrics.HTTPReqsName {
+					url, _ := sample.Tags.Get(metrics.TagURL.String())
+					status, _ := sample.Tags.Get(metrics.TagStatus.String())
+					reqs[url] = status
+				}
+			}
+		}
+		assert.Equal(t, map[string]string{
+			sr("HTTPBIN_IP_URL/sse-gateway"): "302",
+			sr("HTTPBIN_IP_URL/sse-region"):  "302",
+			sr("HTTPBIN_IP_URL/sse"):         "200",
+		}, reqs)
+		assertSseCount(t, samplesBuf, sr("HTTPBIN_IP_URL/sse"), 2)
+	})
+
+	testCases := []struct {
+		name         string
+		params       string
+		maxRedirects null.Int
+		status       int
+	}{
+		{name: "redirects param", params: `{redirects: 1}`, status: http.StatusFound},
+		{name: "maxRedirects param", params: `{maxRedirects: 2}`, status: http.StatusOK},
+		{name: "global option", params: `{}`, maxRedirects: null.IntFrom(0), status: http.StatusFound},
+		{name: "param overrides global option", params: `{redirects: 5}`, maxRedirects: null.IntFrom(0), status: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			test := newTestState(t)
+			sr := test.tb.Replacer.Replace
+			registerRedirects(test)
+			test.VU.StateField.Options.MaxRedirects = tc.maxRedirects
+
+			rt := test.VU.Runtime()
+			require.NoError(t, rt.Set("expected", tc.status))
+			_, err := rt.RunString(sr(`
+			var res = sse.open("HTTPBIN_IP_URL/sse-gateway", ` + tc.params + `, function(client){});
+			if (res.status !== expected) {
+				throw new Error("unexpected status: " + res.status);
+			}
+			`))
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("body is replayed on 307", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		registerRedirects(test)
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		for (var body of ["ok", new Uint8Array([0x6f, 0x6b]).buffer]) {
+			var data = null;
+			var res = sse.open("HTTPBIN_IP_URL/sse-upload", {method: "POST", body: body}, function(client){
+				client.on("event", function(event) {
+					data = event.data;
+				});
+			});
+			if (res.status !== 200 || data !== "6f6b") {
+				throw new Error("unexpected response: " + res.status + " " + data);
+			}
+		}
+		`))
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid redirects", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		sse.open("HTTPBIN_IP_URL/sse", {redirects: -1}, function(client){});
+		`))
+		require.ErrorContains(t, err, "invalid sse.open() redirects: must be positive")
+	})
+}
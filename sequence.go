@@ -0,0 +1,137 @@
+package sse
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.k6.io/k6/metrics"
+)
+
+// Supported event id sequences.
+const (
+	sequenceInteger = "integer"
+	sequenceLexical = "lexical"
+)
+
+// SequenceError is raised when an event id is received out of order.
+type SequenceError struct {
+	ID       string
+	Previous string
+}
+
+func (e *SequenceError) Error() string {
+	return fmt.Sprintf("event id %q received out of order after %q", e.ID, e.Previous)
+}
+
+// sequenceWindow is the number of the last event ids remembered to detect duplicates,
+// so an id delivered again after a resume is counted as a duplicate, not as out of order.
+const sequenceWindow = 1024
+
+// sequenceTracker tracks the ids of the received events to detect gaps,
+// duplicates and out of order delivery.
+type sequenceTracker struct {
+	mode    string
+	started bool
+	last    string
+	lastInt int64
+
+	// seen holds the ids of the window, ring the same ids in the order they were received
+	seen map[string]struct{}
+	ring []string
+	next int
+}
+
+// track records the event id and returns the number of missing ids before it
+// and whether it was already received within the window. Events without id are ignored.
+func (s *sequenceTracker) track(id string) (int64, bool, error) {
+	if id == "" {
+		return 0, false, nil
+	}
+	if _, ok := s.seen[id]; ok {
+		return 0, true, nil
+	}
+
+	if s.mode == sequenceInteger {
+		return s.trackInteger(id)
+	}
+
+	if s.started && id < s.last {
+		return 0, false, &SequenceError{ID: id, Previous: s.last}
+	}
+	s.started = true
+	s.last = id
+	s.remember(id)
+	return 0, false, nil
+}
+
+func (s *sequenceTracker) trackInteger(id string) (int64, bool, error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid integer event id %q", id)
+	}
+
+	var gap int64
+	switch {
+	case !s.started:
+	case n <= s.lastInt:
+		return 0, false, &SequenceError{ID: id, Previous: s.last}
+	default:
+		gap = n - s.lastInt - 1
+	}
+	s.started = true
+	s.last = id
+	s.lastInt = n
+	s.remember(id)
+	return gap, false, nil
+}
+
+// remember adds the id to the window, forgetting the oldest one once full.
+func (s *sequenceTracker) remember(id string) {
+	if s.seen == nil {
+		s.seen = make(map[string]struct{}, sequenceWindow)
+		s.ring = make([]string, sequenceWindow)
+	}
+	if old := s.ring[s.next]; old != "" {
+		delete(s.seen, old)
+	}
+	s.ring[s.next] = id
+	s.seen[id] = struct{}{}
+	s.next = (s.next + 1) % sequenceWindow
+}
+
+// checkSequence emits the gaps and duplicates metrics of the event
+// and forwards sequence errors to the error handlers.
+func (c *Client) checkSequence(ev Event, received time.Time) {
+	if c.sequence == nil {
+		return
+	}
+
+	gap, duplicate, err := c.sequence.track(ev.ID)
+	if err != nil {
+		c.handleEvent("error", c.rt.ToValue(err))
+		return
+	}
+
+	var metric *metrics.Metric
+	value := 1.0
+	switch {
+	case duplicate:
+		metric = c.sseMetrics.SSEEventDuplicates
+	case gap > 0:
+		metric = c.sseMetrics.SSEEventGaps
+		value = float64(gap)
+	default:
+		return
+	}
+
+	metrics.PushIfNotDone(c.ctx, c.samplesOutput, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: metric,
+			Tags:   c.tagsAndMeta.Tags,
+		},
+		Time:     received,
+		Metadata: c.tagsAndMeta.Metadata,
+		Value:    value,
+	})
+}
@@ -0,0 +1,114 @@
+package sse
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/metrics"
+)
+
+func TestSequenceTracker(t *testing.T) {
+	t.Parallel()
+
+	type step struct {
+		id         string
+		gap        int64
+		duplicate  bool
+		outOfOrder bool
+	}
+
+	testCases := []struct {
+		mode  string
+		steps []step
+	}{
+		{
+			mode: sequenceInteger,
+			steps: []step{
+				{id: "10"}, {id: "11"}, {id: "11", duplicate: true}, {id: ""}, {id: "15", gap: 3},
+				{id: "12", outOfOrder: true}, {id: "16"}, {id: "10", duplicate: true}, {id: "17"},
+			},
+		},
+		{
+			mode: sequenceLexical,
+			steps: []step{
+				{id: "2024-01-01T00:00:01"}, {id: "2024-01-01T00:00:01", duplicate: true},
+				{id: "2024-01-01T00:00:00", outOfOrder: true}, {id: "2024-01-01T00:00:03"},
+				{id: "2024-01-01T00:00:01", duplicate: true},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.mode, func(t *testing.T) {
+			t.Parallel()
+			tracker := &sequenceTracker{mode: tc.mode}
+			for _, s := range tc.steps {
+				gap, duplicate, err := tracker.track(s.id)
+				assert.Equal(t, s.gap, gap, s.id)
+				assert.Equal(t, s.duplicate, duplicate, s.id)
+				var seqErr *SequenceError
+				assert.Equal(t, s.outOfOrder, errors.As(err, &seqErr), s.id)
+			}
+		})
+	}
+
+	t.Run("window", func(t *testing.T) {
+		t.Parallel()
+		tracker := &sequenceTracker{mode: sequenceInteger}
+		for i := 1; i <= sequenceWindow+1; i++ {
+			_, _, err := tracker.track(strconv.Itoa(i))
+			require.NoError(t, err)
+		}
+		_, duplicate, _ := tracker.track(strconv.Itoa(sequenceWindow + 1))
+		assert.True(t, duplicate)
+		_, duplicate, err := tracker.track("1")
+		assert.False(t, duplicate, "the id is no longer in the window")
+		var seqErr *SequenceError
+		assert.ErrorAs(t, err, &seqErr)
+	})
+
+	_, _, err := (&sequenceTracker{mode: sequenceInteger}).track("abc")
+	require.Error(t, err)
+}
+
+func TestSequence(t *testing.T) {
+	t.Parallel()
+	test := newTestState(t)
+	sr := test.tb.Replacer.Replace
+
+	test.tb.Mux.HandleFunc("/sse-sequence", func(w http.ResponseWriter, _ *http.Request) {
+		for _, id := range []string{"1", "2", "2", "5", "3", "6"} {
+			_, err := w.Write([]byte("id: " + id + "\ndata: " + id + "\n\n"))
+			require.NoError(t, err)
+		}
+	})
+
+	_, err := test.VU.Runtime().RunString(sr(`
+	var errors = [];
+	sse.open("HTTPBIN_IP_URL/sse-sequence", {sequence: "integer"}, function(client){
+		client.on("error", function(err) {
+			errors.push(err.error());
+		});
+	});
+	if (errors.length !== 1 || errors[0] !== 'event id "3" received out of order after "5"') {
+		throw new Error("unexpected errors: " + JSON.stringify(errors));
+	}
+	`))
+	require.NoError(t, err)
+
+	samplesBuf := metrics.GetBufferedSamples(test.samples)
+	url := sr("HTTPBIN_IP_URL/sse-sequence")
+	assertMetricEmittedCount(t, MetricEventDuplicatesName, samplesBuf, url, 1)
+	assertMetricEmittedCount(t, MetricEventGapsName, samplesBuf, url, 1)
+	for _, sampleContainer := range samplesBuf {
+		for _, sample := range sampleContainer.GetSamples() {
+			if sample.Metric.Name == MetricEventGapsName {
+				assert.Equal(t, 2.0, sample.Value)
+			}
+		}
+	}
+}
@@ -6,20 +6,20 @@
 package sse
 
 import (
-	"bufio"
-	"bytes"
 	"context"
-	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net"
 	"net/http"
 	"net/http/cookiejar"
 	"net/http/httptrace"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/grafana/sobek"
@@ -27,15 +27,17 @@ import (
 	"go.k6.io/k6/js/modules"
 	httpModule "go.k6.io/k6/js/modules/k6/http"
 	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/netext"
 	"go.k6.io/k6/metrics"
 )
 
 type (
 	// sse represents a module instance of the sse module.
 	sse struct {
-		vu      modules.VU
-		obj     *sobek.Object
-		metrics *sseMetrics
+		vu            modules.VU
+		obj           *sobek.Object
+		metrics       *sseMetrics
+		activeStreams *atomic.Int64
 	}
 )
 
@@ -46,6 +48,9 @@ var ErrSSEInInitContext = common.NewInitContextError("using sse in the init cont
 type Client struct {
 	rt            *sobek.Runtime
 	ctx           context.Context
+	state         *lib.State
+	args          *sseOpenArgs
+	requestURL    string
 	url           string
 	resp          *http.Response
 	eventHandlers map[string][]sobek.Callable
@@ -58,32 +63,103 @@ type Client struct {
 	sseMetrics     *sseMetrics
 	cancelRequest  context.CancelFunc
 	httpClient     *http.Client
+	tracer         *streamTracer
+	latency        *latencyOptions
+	sequence       *sequenceTracker
+
+	tlsInfo        netext.TLSInfo
+	urlGrouped     bool
+	redirects      int
+	hopStart       time.Time
+	connStart      time.Time
+	closeReason    string
+	eventsReceived int64
+	wireBytes      atomic.Int64
+	decodedBytes   atomic.Int64
+
+	dialer             lib.DialContexter
+	bytesRead          atomic.Int64
+	bytesWritten       atomic.Int64
+	bytesReadPushed    int64
+	bytesWrittenPushed int64
+	activeStreams      *atomic.Int64
+	active             bool
+
+	closed      bool
+	lastEventID string
+	retryHint   atomic.Int64
+
+	throttled  bool
+	retryAfter time.Duration
+
+	endpoints *endpoints
+
+	openedAt    time.Time
+	handlerTime time.Duration
+
+	validation *ValidationReport
 }
 
 // HTTPResponse is the http response returned by sse.open.
 type HTTPResponse struct {
-	URL     string            `json:"url"`
-	Status  int               `json:"status"`
-	Headers map[string]string `json:"headers"`
-	Error   string            `json:"error"`
+	URL            string            `json:"url"`
+	Status         int               `json:"status"`
+	Headers        map[string]string `json:"headers"`
+	TLSVersion     string            `json:"tls_version"`
+	TLSCipherSuite string            `json:"tls_cipher_suite"`
+	Throttled      bool              `json:"throttled"`
+	RetryAfter     float64           `json:"retry_after"`
+	Validation     *ValidationReport `json:"validation"`
+	Error          string            `json:"error"`
 }
 
 // Event represents a Server-Sent Event
 type Event struct {
-	ID      string
+	ID string
+	// Comment is the last comment of the event, the comments are also dispatched to the comment handlers
 	Comment string
 	Name    string
 	Data    string
+
+	// parsed is the time the event was parsed, before waiting to be dispatched
+	parsed time.Time
 }
 
 type sseOpenArgs struct {
 	setupFn     sobek.Callable
 	headers     http.Header
 	method      string
-	body        string
+	body        *requestBody
+	query       url.Values
 	cookieJar   *cookiejar.Jar
 	tagsAndMeta *metrics.TagsAndMeta
 	timeout     time.Duration
+	heartbeat   *heartbeatOptions
+	tracing     *tracingOptions
+	latency     *latencyOptions
+	sequence    string
+	tls         *tlsOptions
+	auth        *authOptions
+	redirects   int64
+	urlGrouped  bool
+	compression string
+
+	flushInterval time.Duration
+	reconnect     *reconnectOptions
+	retryAfter    *retryAfterOptions
+
+	endpoints []string
+	failover  string
+
+	queue *queueOptions
+	batch *batchOptions
+
+	limits    parserLimits
+	oversized string
+
+	validate bool
+
+	headersProvider sobek.Callable
 }
 
 // Exports returns the exports of the sse module.
@@ -92,7 +168,8 @@ func (mi *sse) Exports() modules.Exports {
 }
 
 // Open establishes a http client connection based on the parameters provided.
-func (mi *sse) Open(url string, args ...sobek.Value) (*HTTPResponse, error) {
+// The url is either a string or an http.url tagged template, or a list of them to fail over.
+func (mi *sse) Open(urlV sobek.Value, args ...sobek.Value) (*HTTPResponse, error) {
 	ctx := mi.vu.Context()
 	rt := mi.vu.Runtime()
 	state := mi.vu.State()
@@ -105,11 +182,41 @@ func (mi *sse) Open(url string, args ...sobek.Value) (*HTTPResponse, error) {
 		return nil, err
 	}
 
-	parsedArgs.tagsAndMeta.SetSystemTagOrMetaIfEnabled(state.Options.SystemTags, metrics.TagURL, url)
+	parsedArgs.endpoints, err = resolveEndpoints(state, rt, urlV, parsedArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	// An iterator body is consumed by the first connection
+	if parsedArgs.body.isOneShot() && (parsedArgs.reconnect != nil || len(parsedArgs.endpoints) > 1) {
+		return nil, fmt.Errorf("invalid sse.open() body: %w", errOneShotBody)
+	}
+
+	var url string
+	if parsedArgs.endpoints != nil {
+		url = parsedArgs.endpoints[0]
+	} else {
+		url, err = resolveURL(state, urlV, parsedArgs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	urls := parsedArgs.endpoints
+	if urls == nil {
+		urls = []string{url}
+	}
+	if err := parsedArgs.auth.checkCredentials(urls...); err != nil {
+		return nil, fmt.Errorf("invalid sse.open() auth: %w", err)
+	}
 
 	client, connEndHook, err := mi.open(ctx, state, rt, url, parsedArgs)
-	defer connEndHook()
+	defer func() {
+		connEndHook()
+		client.tracer.end()
+	}()
 	if err != nil {
+		client.tracer.fail(err)
 		// Pass the error to the user script before exiting immediately
 		client.handleEvent("error", rt.ToValue(err))
 		if state.Options.Throw.Bool {
@@ -120,51 +227,169 @@ func (mi *sse) Open(url string, args ...sobek.Value) (*HTTPResponse, error) {
 
 	// Run the user-provided set up function
 	if _, err := parsedArgs.setupFn(sobek.Undefined(), rt.ToValue(&client)); err != nil {
-		_ = client.closeResponseBody()
+		_ = client.closeWith(closeReasonError)
 		return nil, err
 	}
 
 	// The connection is now open, emit the event
 	client.handleEvent("open")
 
-	readEventChan := make(chan Event)
-	readErrChan := make(chan error)
-	readCloseChan := make(chan int)
-
 	// Wraps a couple of channels
-	go client.readEvents(readEventChan, readErrChan, readCloseChan)
+	reader := client.read()
+	done := client.done
+
+	heartbeat := newHeartbeat(parsedArgs.heartbeat)
+	defer heartbeat.stop()
+
+	flushTicker := time.NewTicker(parsedArgs.flushInterval)
+	defer flushTicker.Stop()
+
+	// Events are dispatched one by one, or in batches if the batch param is set
+	batch := newBatcher(parsedArgs.batch)
+	dispatch := func(event Event) {
+		if batch == nil {
+			client.dispatchEvent(event)
+		} else if batch.add(event) {
+			client.dispatchBatch(batch.take())
+		}
+	}
+
+	reconnect := newReconnector(parsedArgs.reconnect)
+	var reconnectTimer <-chan time.Time
+	var connectErr error
+
+	// closeStream dispatches the close event once the stream is not reconnected
+	closeStream := func() (*HTTPResponse, error) {
+		client.warnSlowHandlers()
+		client.handleEvent("close", rt.ToValue(client.closeEvent()))
+		if client.resp == nil {
+			return client.wrapHTTPResponse(connectErr.Error()), nil
+		}
+		return client.wrapHTTPResponse(""), nil
+	}
+
+	// scheduleReconnect waits before the next attempt, false if the stream must be closed
+	scheduleReconnect := func() bool {
+		delay, ok := reconnect.next(client.closeReason, client.retryHint.Load())
+		if !ok || client.closed {
+			return false
+		}
+		client.endpoints.fail()
+		heartbeat.stop()
+		client.pushReconnect(delay)
+		client.handleEvent("reconnecting", rt.ToValue(&ReconnectEvent{
+			Attempt: reconnect.attempt,
+			Delay:   metrics.D(delay),
+			Reason:  client.closeReason,
+		}))
+		if client.closed {
+			client.closeReason = closeReasonClient
+			return false
+		}
+		reconnectTimer = time.After(delay)
+		return true
+	}
 
 	// This is the main control loop. All JS code (including error handlers)
 	// should only be executed by this thread to avoid race conditions
 	for {
 		select {
-		case event := <-readEventChan:
-			metrics.PushIfNotDone(ctx, client.samplesOutput, metrics.Sample{
-				TimeSeries: metrics.TimeSeries{
-					Metric: client.sseMetrics.SSEEventReceived,
-					Tags:   client.tagsAndMeta.Tags,
-				},
-				Time:     time.Now(),
-				Metadata: client.tagsAndMeta.Metadata,
-				Value:    1,
-			})
+		case event := <-reader.events:
+			heartbeat.reset()
+			client.recordEvent(event, time.Now())
+
+			dispatch(event)
 
-			client.handleEvent("event", rt.ToValue(event))
+		case <-reader.queued():
+			// Queued events are received once parsed, regardless of the time they waited in the queue
+			event, dropped, ok := reader.queue.pop()
+			client.pushDropped(dropped, time.Now())
+			if ok {
+				heartbeat.reset()
+				client.recordEvent(event, event.parsed)
 
-		case readErr := <-readErrChan:
+				dispatch(event)
+			}
+
+		case comment := <-reader.comments:
+			heartbeat.reset()
+			client.recordComment(time.Now())
+
+			client.handleEvent("comment", rt.ToValue(comment))
+
+		case <-heartbeat.C():
+			client.handleEvent("error", rt.ToValue(heartbeat.err()))
+			if heartbeat.opts.close {
+				_ = client.closeWith(closeReasonHeartbeat)
+			} else {
+				heartbeat.reset()
+			}
+
+		case <-batch.C():
+			client.dispatchBatch(batch.take())
+
+		case readErr := <-reader.errors:
+			client.checkOversized(readErr)
 			client.handleEvent("error", rt.ToValue(readErr))
 
+		case violation := <-reader.violations:
+			client.recordViolation(violation)
+
+		case t := <-flushTicker.C:
+			client.pushDataMetrics(t)
+			client.pushStreamMetrics(t)
+
 		case <-ctx.Done():
 			// VU is shutting down during an interrupt
 			// client events will not be forwarded to the VU
-			_ = client.closeResponseBody()
+			if reconnectTimer != nil {
+				client.closeReason = closeReasonContext
+				return closeStream()
+			}
+			_ = client.closeWith(closeReasonContext)
+
+		case readErr := <-reader.closed:
+			client.checkOversized(readErr)
+			client.onReadClose(readErr)
+
+		case <-done:
+			// This is the final exit point normally triggered by closeResponseBody,
+			// unless the stream is reconnected
+			client.dispatchBatch(batch.take())
+			connEndHook()
+			connEndHook = func() {}
+			done = nil
+			if !scheduleReconnect() {
+				return closeStream()
+			}
 
-		case <-readCloseChan:
-			_ = client.closeResponseBody()
+		case <-reconnectTimer:
+			reconnectTimer = nil
+			// The span of the previous connection is ended once, even if the new one fails before its tracer is created
+			client.tracer.end()
+			client.tracer = nil
+			connEndHook, connectErr = client.connectFailover()
+			if connectErr == nil && (client.resp.StatusCode < 200 || client.resp.StatusCode >= 300) {
+				connectErr = fmt.Errorf("unexpected status %d", client.resp.StatusCode)
+				_ = client.closeResponseBody()
+			}
+			if connectErr != nil {
+				client.tracer.fail(connectErr)
+				client.handleEvent("error", rt.ToValue(connectErr))
+				client.closeReason = closeReasonError
+				connEndHook()
+				connEndHook = func() {}
+				if !scheduleReconnect() {
+					return closeStream()
+				}
+				continue
+			}
 
-		case <-client.done:
-			// This is the final exit point normally triggered by closeResponseBody
-			return client.wrapHTTPResponse(""), nil
+			reconnect.reset()
+			heartbeat.reset()
+			reader = client.read()
+			done = client.done
+			client.handleEvent("open")
 		}
 	}
 }
@@ -172,43 +397,94 @@ func (mi *sse) Open(url string, args ...sobek.Value) (*HTTPResponse, error) {
 func (mi *sse) open(ctx context.Context, state *lib.State, rt *sobek.Runtime,
 	url string, args *sseOpenArgs,
 ) (*Client, func(), error) {
-	reqCtx, cancel := context.WithCancel(ctx)
-
-	sseClient := Client{
+	sseClient := &Client{
 		ctx:            ctx,
 		rt:             rt,
-		url:            url,
+		state:          state,
+		args:           args,
+		requestURL:     url,
+		openedAt:       time.Now(),
 		eventHandlers:  make(map[string][]sobek.Callable),
-		done:           make(chan struct{}),
 		samplesOutput:  state.Samples,
 		tagsAndMeta:    args.tagsAndMeta,
 		builtinMetrics: state.BuiltinMetrics,
 		sseMetrics:     mi.metrics,
-		cancelRequest:  cancel,
+		latency:        args.latency,
+		urlGrouped:     args.urlGrouped,
+		dialer:         state.Dialer,
+		activeStreams:  mi.activeStreams,
+	}
+
+	if args.sequence != "" {
+		sseClient.sequence = &sequenceTracker{mode: args.sequence}
+	}
+	if args.endpoints != nil {
+		sseClient.endpoints = newEndpoints(args.endpoints, args.failover)
+	}
+	if args.validate {
+		sseClient.validation = &ValidationReport{Valid: true}
+	}
+	// No reconnection time hint until the server sends one
+	sseClient.retryHint.Store(-1)
+
+	connEndHook, err := sseClient.connectFailover()
+	return sseClient, connEndHook, err
+}
+
+// connect opens a new connection of the stream, resetting the state of the previous one.
+// The returned hook pushes the metrics of the connection once it is closed.
+func (c *Client) connect() (func(), error) {
+	state, args := c.state, c.args
+	reqCtx, cancel := context.WithCancel(c.ctx)
+
+	// The context of the previous connection, whose body is already closed, is released
+	if c.cancelRequest != nil {
+		c.cancelRequest()
+	}
+	c.cancelRequest = cancel
+	c.done = make(chan struct{})
+	c.shutdownOnce = sync.Once{}
+	c.url = c.requestURL
+	c.resp = nil
+	c.closeReason = ""
+	c.throttled = false
+	c.retryAfter = -1
+	c.redirects = 0
+	c.tlsInfo = netext.TLSInfo{}
+	c.wireBytes.Store(0)
+	c.decodedBytes.Store(0)
+
+	tlsConfig, err := args.tls.apply(state.TLSConfig, c.requestURL)
+	if err != nil {
+		return func() {}, err
 	}
 
 	// Overriding the NextProtos to avoid talking http2
-	var tlsConfig *tls.Config
-	if state.TLSConfig != nil {
-		tlsConfig = state.TLSConfig.Clone()
+	if tlsConfig != nil {
+		if tlsConfig == state.TLSConfig {
+			tlsConfig = tlsConfig.Clone()
+		}
 		tlsConfig.NextProtos = []string{"http/1.1"}
 	}
 
-	sseClient.httpClient = &http.Client{
+	c.httpClient = &http.Client{
 		// FUTURE: support falling back on global timeout re: https://github.com/grafana/k6/issues/3932
 		Timeout: args.timeout,
 		Transport: &http.Transport{
-			DialContext:     state.Dialer.DialContext,
+			DialContext:     c.dialContext(state.Dialer),
 			Proxy:           http.ProxyFromEnvironment,
 			TLSClientConfig: tlsConfig,
 			// FIXME phymbert: it would be more interesting to allow reusing the transport across iterations
 			DisableKeepAlives: state.Options.NoConnectionReuse.ValueOrZero() || state.Options.NoVUConnectionReuse.ValueOrZero(),
+			// The stream is decoded while read to account for both wire and decoded bytes
+			DisableCompression: args.compression != "",
 		},
+		CheckRedirect: c.checkRedirect(args.redirects, state.Options.SystemTags),
 	}
 
 	// httpClient.Jar must never be nil
 	if args.cookieJar != nil {
-		sseClient.httpClient.Jar = args.cookieJar
+		c.httpClient.Jar = args.cookieJar
 	}
 
 	httpMethod := http.MethodGet
@@ -216,9 +492,17 @@ func (mi *sse) open(ctx context.Context, state *lib.State, rt *sobek.Runtime,
 		httpMethod = args.method
 	}
 
-	req, err := http.NewRequestWithContext(reqCtx, httpMethod, url, strings.NewReader(args.body))
+	body, bodyLength, err := args.body.reader()
 	if err != nil {
-		return &sseClient, nil, err
+		return func() {}, err
+	}
+
+	req, err := http.NewRequestWithContext(reqCtx, httpMethod, c.requestURL, body)
+	if err != nil {
+		return func() {}, err
+	}
+	if bodyLength >= 0 {
+		req.ContentLength = bodyLength
 	}
 
 	req.Header.Set("Accept", "text/event-stream")
@@ -227,6 +511,41 @@ func (mi *sse) open(ctx context.Context, state *lib.State, rt *sobek.Runtime,
 			req.Header.Set(headerName, headerValue)
 		}
 	}
+	if args.body != nil && args.body.contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", args.body.contentType)
+	}
+	if args.compression != "" {
+		req.Header.Set("Accept-Encoding", args.compression)
+	}
+	// Resume the stream from the last event received when reconnecting
+	if c.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", c.lastEventID)
+	}
+
+	c.httpClient.Transport = args.auth.apply(req, c.httpClient.Transport)
+
+	// Provided headers are evaluated on each connection and take precedence
+	if args.headersProvider != nil {
+		providedHeaders, err := headersFromProvider(c.rt, args.headersProvider)
+		if err != nil {
+			return func() {}, fmt.Errorf("sse.open() headersProvider: %w", err)
+		}
+		for headerName, headerValues := range providedHeaders {
+			req.Header[headerName] = headerValues
+		}
+	}
+
+	// Propagate the trace context and attach the trace id to the samples
+	if args.tracing != nil {
+		c.tracer, err = newStreamTracer(reqCtx, state, args.tracing)
+		if err != nil {
+			return func() {}, err
+		}
+		c.tracer.inject(req.Header)
+		// The samples already pushed keep the metadata of their connection
+		args.tagsAndMeta.Metadata = maps.Clone(args.tagsAndMeta.Metadata)
+		args.tagsAndMeta.SetMetadata(metadataTraceID, c.tracer.traceID.String())
+	}
 
 	// Wrap the request to retrieve the server IP tag
 	trace := &httptrace.ClientTrace{
@@ -237,27 +556,52 @@ func (mi *sse) open(ctx context.Context, state *lib.State, rt *sobek.Runtime,
 				}
 			}
 		},
+		GotFirstResponseByte: func() {
+			c.tracer.firstByte()
+		},
 	}
 
 	//nolint:contextcheck // parent context already passed in the request
 	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
 
 	connStart := time.Now()
+	c.connStart = connStart
+	c.hopStart = connStart
 	//nolint:bodyclose // Body is deferred closed in closeResponseBody
-	resp, err := sseClient.httpClient.Do(req)
+	resp, err := c.do(req, args.body)
 	connEnd := time.Now()
 
 	if resp != nil {
-		sseClient.resp = resp
+		c.resp = resp
 		if state.Options.SystemTags.Has(metrics.TagStatus) {
 			args.tagsAndMeta.SetSystemTagOrMeta(
 				metrics.TagStatus, strconv.Itoa(resp.StatusCode))
 		}
+		// The response url and tags are the ones of the last redirection
+		if c.redirects > 0 {
+			c.url = resp.Request.URL.String()
+			if !args.urlGrouped {
+				setURLTags(args.tagsAndMeta, state.Options.SystemTags, resp.Request.URL)
+			}
+		}
+		c.checkThrottled(resp, connEnd)
+		// Only the accepted stream is validated, not the responses rejected by throttling or failover
+		if c.validation != nil && isSuccess(resp) {
+			c.validateHeaders(resp)
+		}
+		if resp.TLS != nil {
+			c.tlsInfo, _ = netext.ParseTLSConnState(resp.TLS)
+			args.tagsAndMeta.SetSystemTagOrMetaIfEnabled(state.Options.SystemTags, metrics.TagTLSVersion, c.tlsInfo.Version)
+		}
+	}
+
+	if err == nil && resp != nil {
+		c.streamOpened()
 	}
 
-	connEndHook := sseClient.pushSSEMetrics(connStart, connEnd)
+	connEndHook := c.pushSSEMetrics(connStart, connEnd)
 
-	return &sseClient, connEndHook, err
+	return connEndHook, err
 }
 
 // On is used to configure what the client should do on each event.
@@ -269,7 +613,8 @@ func (c *Client) On(event string, handler sobek.Value) {
 
 // Close the event loop
 func (c *Client) Close() error {
-	err := c.closeResponseBody()
+	c.closed = true
+	err := c.closeWith(closeReasonClient)
 	c.cancelRequest()
 	c.httpClient.CloseIdleConnections()
 	return err
@@ -301,6 +646,39 @@ func (c *Client) closeResponseBody() error {
 	return err
 }
 
+// recordEvent pushes the metrics of an event received at the given time.
+func (c *Client) recordEvent(ev Event, received time.Time) {
+	c.eventsReceived++
+	if ev.ID != "" {
+		c.lastEventID = ev.ID
+	}
+	metrics.PushIfNotDone(c.ctx, c.samplesOutput, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: c.sseMetrics.SSEEventReceived,
+			Tags:   c.tagsAndMeta.Tags,
+		},
+		Time:     received,
+		Metadata: c.tagsAndMeta.Metadata,
+		Value:    1,
+	})
+	c.pushEventLatency(ev, received)
+	c.checkSequence(ev, received)
+	c.tracer.event()
+}
+
+// recordComment pushes the metric of a comment received at the given time.
+func (c *Client) recordComment(received time.Time) {
+	metrics.PushIfNotDone(c.ctx, c.samplesOutput, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: c.sseMetrics.SSEComments,
+			Tags:   c.tagsAndMeta.Tags,
+		},
+		Time:     received,
+		Metadata: c.tagsAndMeta.Metadata,
+		Value:    1,
+	})
+}
+
 func (c *Client) pushSSEMetrics(connStart, connEnd time.Time) func() {
 	connDuration := metrics.D(connEnd.Sub(connStart))
 
@@ -347,7 +725,7 @@ func (c *Client) pushSSEMetrics(connStart, connEnd time.Time) func() {
 				{
 					TimeSeries: metrics.TimeSeries{
 						Metric: c.builtinMetrics.HTTPReqDuration,
-						Tags:   c.tagsAndMeta.Tags,
+						Tags:   c.closeReasonTags(),
 					},
 					Time:     end,
 					Metadata: c.tagsAndMeta.Metadata,
@@ -357,101 +735,200 @@ func (c *Client) pushSSEMetrics(connStart, connEnd time.Time) func() {
 			Tags: c.tagsAndMeta.Tags,
 			Time: end,
 		})
+
+		c.streamClosed(end)
+		c.pushDataMetrics(end)
+		if c.resp != nil {
+			c.pushBytesMetrics(end)
+		}
 	}
 }
 
+// pushBytesMetrics pushes the bytes of the stream received on the wire and once decoded.
+func (c *Client) pushBytesMetrics(t time.Time) {
+	metrics.PushIfNotDone(c.ctx, c.samplesOutput, metrics.ConnectedSamples{
+		Samples: []metrics.Sample{
+			{
+				TimeSeries: metrics.TimeSeries{
+					Metric: c.sseMetrics.SSEWireBytes,
+					Tags:   c.tagsAndMeta.Tags,
+				},
+				Time:     t,
+				Metadata: c.tagsAndMeta.Metadata,
+				Value:    float64(c.wireBytes.Load()),
+			},
+			{
+				TimeSeries: metrics.TimeSeries{
+					Metric: c.sseMetrics.SSEDecodedBytes,
+					Tags:   c.tagsAndMeta.Tags,
+				},
+				Time:     t,
+				Metadata: c.tagsAndMeta.Metadata,
+				Value:    float64(c.decodedBytes.Load()),
+			},
+		},
+		Tags: c.tagsAndMeta.Tags,
+		Time: t,
+	})
+}
+
+// streamReader holds the channels the reader of a connection sends to the control loop.
+// The events go through the queue instead of the events channel if the queue param is set.
+type streamReader struct {
+	events     chan Event
+	comments   chan string
+	errors     chan error
+	closed     chan error
+	violations chan ProtocolViolation
+	queue      *eventQueue
+}
+
+// send passes the event to the control loop, false if the connection is done.
+func (r *streamReader) send(ev Event, done chan struct{}) bool {
+	if r.queue != nil {
+		return r.queue.push(ev, done)
+	}
+	select {
+	case r.events <- ev:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// queued returns the channel signaled once events are queued, nil without queue.
+func (r *streamReader) queued() chan struct{} {
+	if r.queue == nil {
+		return nil
+	}
+	return r.queue.ready
+}
+
+// read starts reading the current connection. The channels of each connection are distinct,
+// so nothing read from a previous connection is received once reconnected.
+func (c *Client) read() *streamReader {
+	r := &streamReader{
+		events:     make(chan Event),
+		comments:   make(chan string),
+		errors:     make(chan error),
+		closed:     make(chan error),
+		violations: make(chan ProtocolViolation),
+		queue:      newEventQueue(c.args.queue),
+	}
+	go c.readEvents(c.resp, c.done, r)
+	return r
+}
+
 // Wraps SSE in a channel, follow the SSE format described in:
 // https://developer.mozilla.org/en-US/docs/Web/API/Server-sent_events/Using_server-sent_events
-func (c *Client) readEvents(readChan chan Event, errorChan chan error, closeChan chan int) {
-	reader := bufio.NewReader(c.resp.Body)
-	ev := Event{}
-	var buf bytes.Buffer
+// Parsing errors are sent to the errors channel, the reader stops on a read error or at the end of the stream,
+// the read error (nil at the end of the stream, once the queued events are dispatched) is then sent to the closed channel.
+func (c *Client) readEvents(resp *http.Response, done chan struct{}, r *streamReader) {
+	// The body is only decoded when the compression param is set, otherwise it is passed through
+	var contentEncoding string
+	if c.args.compression != "" {
+		contentEncoding = resp.Header.Get("Content-Encoding")
+	}
+	body, closers, err := decodeBody(countingReader{reader: resp.Body, count: &c.wireBytes}, contentEncoding)
+	defer func() {
+		for _, closer := range closers {
+			_ = closer.Close()
+		}
+	}()
+	if err != nil {
+		select {
+		case r.closed <- err:
+		case <-done:
+		}
+		return
+	}
+
+	parser := newEventParser(countingReader{reader: body, count: &c.decodedBytes}, c.args.limits)
+	defer parser.release()
+	parser.validate = c.args.validate
 
 	for {
-		line, err := reader.ReadBytes('\n')
+		tok, err := parser.next()
 		if err != nil {
 			if errors.Is(err, io.EOF) {
-				select {
-				case closeChan <- -1:
-					return
-				case <-c.done:
-					return
-				}
-			} else {
-				select {
-				case errorChan <- err:
-					return
-				case <-c.done:
+				err = nil
+				if r.queue != nil && !r.queue.drain(done) {
 					return
 				}
 			}
+			select {
+			case r.closed <- err:
+			case <-done:
+			}
+			return
 		}
 
-		switch {
-		// id of event
-		case hasPrefix(line, "id: "):
-			ev.ID = stripPrefix(line, 4)
-		case hasPrefix(line, "id:"):
-			ev.ID = stripPrefix(line, 3)
-
-		// Comment
-		case hasPrefix(line, ": "):
-			ev.Comment = stripPrefix(line, 2)
-		case hasPrefix(line, ":"):
-			ev.Comment = stripPrefix(line, 1)
-
-		// name of event
-		case hasPrefix(line, "event: "):
-			ev.Name = stripPrefix(line, 7)
-		case hasPrefix(line, "event:"):
-			ev.Name = stripPrefix(line, 6)
-
-		// event data
-		case hasPrefix(line, "data: "):
-			buf.Write(line[6:])
-
-		case hasPrefix(line, "data:"):
-			buf.Write(line[5:])
-
-		case hasPrefix(line, "retry:"):
-			// Retry, do nothing for now
-
-		// end of event
-		case isLineEnd(line):
-			// Trailing newlines are removed.
-			ev.Data = strings.TrimRightFunc(buf.String(), func(r rune) bool {
-				return r == '\r' || r == '\n'
-			})
+		switch tok.kind {
+		case tokenEvent:
+			tok.event.parsed = time.Now()
+			if !r.send(tok.event, done) {
+				return
+			}
+
+		// Comments are dispatched on their own, they are mostly used as keepalive
+		case tokenComment:
+			select {
+			case r.comments <- tok.comment:
+			case <-done:
+				return
+			}
+
+		case tokenRetry:
+			c.retryHint.Store(tok.retry)
 
+		// Oversized lines or events are reported, and abort the stream if the oversized param is abort
+		case tokenOversized:
+			if c.args.oversized == oversizedAbort {
+				select {
+				case r.closed <- tok.err:
+				case <-done:
+				}
+				return
+			}
 			select {
-			case readChan <- ev:
-				buf.Reset()
-				ev = Event{}
-			case <-c.done:
+			case r.errors <- tok.err:
+			case <-done:
 				return
 			}
-		default:
+
+		case tokenInvalid:
 			select {
-			case errorChan <- errors.New("unknown event: " + string(line)):
-			case <-c.done:
+			case r.errors <- errors.New("unknown event: " + tok.line):
+			case <-done:
+				return
+			}
+
+		// Deviations from the specification are only recorded in strict validation
+		case tokenViolation:
+			select {
+			case r.violations <- tok.violation:
+			case <-done:
 				return
 			}
 		}
 	}
 }
 
-func isLineEnd(line []byte) bool {
-	return bytes.Equal(line, []byte("\n")) || bytes.Equal(line, []byte("\r\n"))
-}
-
 // Wrap the raw HTTPResponse we received to a sse.HTTPResponse we can pass to the user
 func (c *Client) wrapHTTPResponse(errMessage string) *HTTPResponse {
 	if errMessage != "" {
 		return &HTTPResponse{Error: errMessage}
 	}
 	sseResponse := HTTPResponse{
-		URL:    c.url,
-		Status: c.resp.StatusCode,
+		URL:            c.url,
+		Status:         c.resp.StatusCode,
+		TLSVersion:     c.tlsInfo.Version,
+		TLSCipherSuite: c.tlsInfo.CipherSuite,
+		Throttled:      c.throttled,
+		Validation:     c.validation,
+	}
+	if c.retryAfter >= 0 {
+		sseResponse.RetryAfter = metrics.D(c.retryAfter)
 	}
 
 	sseResponse.Headers = make(map[string]string, len(c.resp.Header))
@@ -481,16 +958,8 @@ func parseConnectArgs(state *lib.State, rt *sobek.Runtime, args ...sobek.Value)
 		return nil, errors.New("last argument to sse.open must be a function")
 	}
 
-	headers := make(http.Header)
-	headers.Set("User-Agent", state.Options.UserAgent.String)
-	tagsAndMeta := state.Tags.GetCurrentValues()
-	parsedArgs := &sseOpenArgs{
-		setupFn:     setupFn,
-		headers:     headers,
-		cookieJar:   state.CookieJar,
-		tagsAndMeta: &tagsAndMeta,
-		timeout:     0,
-	}
+	parsedArgs := newConnectArgs(state)
+	parsedArgs.setupFn = setupFn
 
 	if sobek.IsUndefined(paramsV) || sobek.IsNull(paramsV) {
 		return parsedArgs, nil
@@ -504,6 +973,28 @@ func parseConnectArgs(state *lib.State, rt *sobek.Runtime, args ...sobek.Value)
 	return parsedArgs, nil
 }
 
+// newConnectArgs returns the connect arguments defaulted from the VU state.
+func newConnectArgs(state *lib.State) *sseOpenArgs {
+	headers := make(http.Header)
+	headers.Set("User-Agent", state.Options.UserAgent.String)
+	tagsAndMeta := state.Tags.GetCurrentValues()
+	redirects := int64(defaultMaxRedirects)
+	if state.Options.MaxRedirects.Valid {
+		redirects = state.Options.MaxRedirects.Int64
+	}
+	return &sseOpenArgs{
+		headers:     headers,
+		cookieJar:   state.CookieJar,
+		tagsAndMeta: &tagsAndMeta,
+		timeout:     0,
+		redirects:   redirects,
+
+		flushInterval: defaultFlushInterval,
+		limits:        defaultParserLimits,
+		oversized:     oversizedSkip,
+	}
+}
+
 func parseConnectOptionalArgs(paramsV sobek.Value, rt *sobek.Runtime, parsedArgs *sseOpenArgs) error {
 	params := paramsV.ToObject(rt)
 	for _, k := range params.Keys() {
@@ -534,8 +1025,16 @@ func parseConnectOptionalArgs(paramsV sobek.Value, rt *sobek.Runtime, parsedArgs
 			}
 		case "method":
 			parsedArgs.method = strings.TrimSpace(params.Get(k).ToString().String())
-		case "body":
-			parsedArgs.body = strings.TrimSpace(params.Get(k).ToString().String())
+		case "body", "json", "form":
+			if err := parseBodyOption(k, params.Get(k), rt, parsedArgs); err != nil {
+				return err
+			}
+		case "query":
+			queryV := params.Get(k)
+			if sobek.IsUndefined(queryV) || sobek.IsNull(queryV) {
+				continue
+			}
+			parsedArgs.query = toValues(rt, queryV)
 		case "timeout":
 			timeoutV := params.Get(k)
 			if sobek.IsUndefined(timeoutV) || sobek.IsNull(timeoutV) {
@@ -546,15 +1045,215 @@ func parseConnectOptionalArgs(paramsV sobek.Value, rt *sobek.Runtime, parsedArgs
 				return fmt.Errorf("invalid sse.open() timeout: %w", err)
 			}
 			parsedArgs.timeout = timeout
+		case "reconnect":
+			reconnectV := params.Get(k)
+			if enabled, ok := reconnectV.Export().(bool); sobek.IsUndefined(reconnectV) || sobek.IsNull(reconnectV) || (ok && !enabled) {
+				continue
+			}
+			reconnect, err := parseReconnectOptions(rt, reconnectV)
+			if err != nil {
+				return fmt.Errorf("invalid sse.open() reconnect: %w", err)
+			}
+			parsedArgs.reconnect = reconnect
+		case "failover":
+			failoverV := params.Get(k)
+			if sobek.IsUndefined(failoverV) || sobek.IsNull(failoverV) {
+				continue
+			}
+			failover, err := parseFailover(failoverV)
+			if err != nil {
+				return fmt.Errorf("invalid sse.open() failover: %w", err)
+			}
+			parsedArgs.failover = failover
+		case "maxLineBytes", "maxEventBytes":
+			limitV := params.Get(k)
+			if sobek.IsUndefined(limitV) || sobek.IsNull(limitV) {
+				continue
+			}
+			limit, err := parseSizeLimit(limitV)
+			if err != nil {
+				return fmt.Errorf("invalid sse.open() %s: %w", k, err)
+			}
+			if k == "maxLineBytes" {
+				parsedArgs.limits.maxLineBytes = limit
+			} else {
+				parsedArgs.limits.maxEventBytes = limit
+			}
+		case "oversized":
+			oversizedV := params.Get(k)
+			if sobek.IsUndefined(oversizedV) || sobek.IsNull(oversizedV) {
+				continue
+			}
+			oversized, err := parseOversized(oversizedV)
+			if err != nil {
+				return fmt.Errorf("invalid sse.open() oversized: %w", err)
+			}
+			parsedArgs.oversized = oversized
+		case "validate":
+			validateV := params.Get(k)
+			if sobek.IsUndefined(validateV) || sobek.IsNull(validateV) {
+				continue
+			}
+			validate, err := parseValidate(validateV)
+			if err != nil {
+				return fmt.Errorf("invalid sse.open() validate: %w", err)
+			}
+			parsedArgs.validate = validate
+		case "batch":
+			batchV := params.Get(k)
+			if sobek.IsUndefined(batchV) || sobek.IsNull(batchV) {
+				continue
+			}
+			batch, err := parseBatchOptions(rt, batchV)
+			if err != nil {
+				return fmt.Errorf("invalid sse.open() batch: %w", err)
+			}
+			parsedArgs.batch = batch
+		case "queue":
+			queueV := params.Get(k)
+			if sobek.IsUndefined(queueV) || sobek.IsNull(queueV) {
+				continue
+			}
+			queue, err := parseQueueOptions(rt, queueV)
+			if err != nil {
+				return fmt.Errorf("invalid sse.open() queue: %w", err)
+			}
+			parsedArgs.queue = queue
+		case "retryAfter":
+			retryAfterV := params.Get(k)
+			if enabled, ok := retryAfterV.Export().(bool); sobek.IsUndefined(retryAfterV) || sobek.IsNull(retryAfterV) || (ok && !enabled) {
+				continue
+			}
+			retryAfter, err := parseRetryAfterOptions(rt, retryAfterV)
+			if err != nil {
+				return fmt.Errorf("invalid sse.open() retryAfter: %w", err)
+			}
+			parsedArgs.retryAfter = retryAfter
+		case "flushInterval":
+			flushIntervalV := params.Get(k)
+			if sobek.IsUndefined(flushIntervalV) || sobek.IsNull(flushIntervalV) {
+				continue
+			}
+			flushInterval, err := time.ParseDuration(flushIntervalV.ToString().String())
+			if err != nil {
+				return fmt.Errorf("invalid sse.open() flushInterval: %w", err)
+			}
+			if flushInterval <= 0 {
+				return errors.New("invalid sse.open() flushInterval: must be positive")
+			}
+			parsedArgs.flushInterval = flushInterval
+		case "heartbeatTimeout", "closeOnHeartbeatTimeout":
+			if err := parseHeartbeatOption(k, params.Get(k), parsedArgs); err != nil {
+				return err
+			}
+		case "tracing":
+			tracingV := params.Get(k)
+			if sobek.IsUndefined(tracingV) || sobek.IsNull(tracingV) {
+				continue
+			}
+			tracing, err := parseTracingOptions(rt, tracingV)
+			if err != nil {
+				return fmt.Errorf("invalid sse.open() tracing: %w", err)
+			}
+			parsedArgs.tracing = tracing
+		case "latency":
+			latencyV := params.Get(k)
+			if sobek.IsUndefined(latencyV) || sobek.IsNull(latencyV) {
+				continue
+			}
+			latency, err := parseLatencyOptions(rt, latencyV)
+			if err != nil {
+				return fmt.Errorf("invalid sse.open() latency: %w", err)
+			}
+			parsedArgs.latency = latency
+		case "sequence":
+			sequenceV := params.Get(k)
+			if sobek.IsUndefined(sequenceV) || sobek.IsNull(sequenceV) {
+				continue
+			}
+			sequence := strings.TrimSpace(sequenceV.String())
+			if sequence != sequenceInteger && sequence != sequenceLexical {
+				return fmt.Errorf("invalid sse.open() sequence: unknown sequence %q", sequence)
+			}
+			parsedArgs.sequence = sequence
+		case "tls":
+			tlsV := params.Get(k)
+			if sobek.IsUndefined(tlsV) || sobek.IsNull(tlsV) {
+				continue
+			}
+			tlsOpts, err := parseTLSOptions(rt, tlsV)
+			if err != nil {
+				return fmt.Errorf("invalid sse.open() tls: %w", err)
+			}
+			parsedArgs.tls = tlsOpts
+		case "redirects", "maxRedirects":
+			redirectsV := params.Get(k)
+			if sobek.IsUndefined(redirectsV) || sobek.IsNull(redirectsV) {
+				continue
+			}
+			redirects := redirectsV.ToInteger()
+			if redirects < 0 {
+				return fmt.Errorf("invalid sse.open() %s: must be positive", k)
+			}
+			parsedArgs.redirects = redirects
+		case "compression":
+			compressionV := params.Get(k)
+			if sobek.IsUndefined(compressionV) || sobek.IsNull(compressionV) {
+				continue
+			}
+			compression, err := parseCompression(rt, compressionV)
+			if err != nil {
+				return fmt.Errorf("invalid sse.open() compression: %w", err)
+			}
+			parsedArgs.compression = compression
+		case "auth":
+			authV := params.Get(k)
+			if sobek.IsUndefined(authV) || sobek.IsNull(authV) {
+				continue
+			}
+			auth, err := parseAuthOptions(authV)
+			if err != nil {
+				return fmt.Errorf("invalid sse.open() auth: %w", err)
+			}
+			parsedArgs.auth = auth
+		case "headersProvider":
+			providerV := params.Get(k)
+			if sobek.IsUndefined(providerV) || sobek.IsNull(providerV) {
+				continue
+			}
+			provider, ok := sobek.AssertFunction(providerV)
+			if !ok {
+				return errors.New("invalid sse.open() headersProvider: must be a function")
+			}
+			parsedArgs.headersProvider = provider
 		}
 	}
+	if parsedArgs.heartbeat != nil && parsedArgs.heartbeat.timeout == 0 {
+		return errors.New("invalid sse.open() closeOnHeartbeatTimeout: requires heartbeatTimeout")
+	}
 	return nil
 }
 
-func hasPrefix(s []byte, prefix string) bool {
-	return bytes.HasPrefix(s, []byte(prefix))
-}
+func parseBodyOption(k string, v sobek.Value, rt *sobek.Runtime, parsedArgs *sseOpenArgs) error {
+	if sobek.IsUndefined(v) || sobek.IsNull(v) {
+		return nil
+	}
+	if parsedArgs.body != nil {
+		return fmt.Errorf("invalid sse.open() %s: %w", k, errBodyAlreadySet)
+	}
+
+	var err error
+	switch k {
+	case "json":
+		parsedArgs.body, err = parseJSONBody(v)
+	case "form":
+		parsedArgs.body = parseFormBody(rt, v)
+	default:
+		parsedArgs.body, err = parseBody(rt, v)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid sse.open() %s: %w", k, err)
+	}
 
-func stripPrefix(line []byte, start int) string {
-	return string(line[start : len(line)-1])
+	return nil
 }
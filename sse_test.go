@@ -129,6 +129,7 @@ func newTestState(tb testing.TB) testState {
 		Options: lib.Options{
 			SystemTags: metrics.NewSystemTagSet(
 				metrics.TagURL,
+				metrics.TagName,
 				metrics.TagProto,
 				metrics.TagStatus,
 				metrics.TagSubproto,
@@ -165,6 +166,7 @@ func TestOpen(t *testing.T) {
 		var open = false;
 		var error = false;
 		var events = [];
+		var comments = [];
 		var res = sse.open("HTTPBIN_IP_URL/sse", function(client){
 			client.on("error", function(err) {
 				error = true
@@ -178,6 +180,9 @@ func TestOpen(t *testing.T) {
 			client.on("event", function(event) {
 				events.push(event);
 			});
+			client.on("comment", function(comment) {
+				comments.push(comment);
+			});
 		});
 		if (!open) {
 			throw new Error("opened is not called");
@@ -185,6 +190,9 @@ func TestOpen(t *testing.T) {
 		if (error) {
 			throw new Error("error raised");
 		}
+		if (comments.length !== 1 || comments[0] !== 'hello') {
+			throw new Error("unexpected comments: " + comments);
+		}
 		for (let i = 0; i < events.length; i++) {
 			let event = events[i];
 			switch(i) {
@@ -330,6 +338,80 @@ func TestClose(t *testing.T) {
 	})
 }
 
+func TestCloseEvent(t *testing.T) {
+	t.Parallel()
+
+	t.Run("by server", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var closeEvent = null;
+		sse.open("HTTPBIN_IP_URL/sse", function(client){
+			client.on("close", function(event) {
+				closeEvent = event;
+			});
+		});
+		if (closeEvent === null) {
+			throw new Error("close is not called");
+		}
+		if (closeEvent.reason !== "server" || !closeEvent.byServer) {
+			throw new Error("unexpected close reason: " + closeEvent.reason);
+		}
+		if (closeEvent.eventsReceived !== 2) {
+			throw new Error("unexpected number of events received: " + closeEvent.eventsReceived);
+		}
+		if (!(closeEvent.duration > 0)) {
+			throw new Error("unexpected duration: " + closeEvent.duration);
+		}
+		`))
+		require.NoError(t, err)
+		assertCloseReasonTag(t, metrics.GetBufferedSamples(test.samples), "server")
+	})
+
+	t.Run("by client", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var closeEvent = null;
+		sse.open("HTTPBIN_IP_URL/sse", function(client){
+			client.on("event", function(event) {
+				client.close();
+			});
+			client.on("close", function(event) {
+				closeEvent = event;
+			});
+		});
+		if (closeEvent.reason !== "client" || closeEvent.byServer) {
+			throw new Error("unexpected close reason: " + closeEvent.reason);
+		}
+		if (closeEvent.eventsReceived !== 1) {
+			throw new Error("unexpected number of events received: " + closeEvent.eventsReceived);
+		}
+		`))
+		require.NoError(t, err)
+		assertCloseReasonTag(t, metrics.GetBufferedSamples(test.samples), "client")
+	})
+}
+
+func assertCloseReasonTag(t *testing.T, sampleContainers []metrics.SampleContainer, reason string) {
+	t.Helper()
+	seen := false
+	for _, sampleContainer := range sampleContainers {
+		for _, sample := range sampleContainer.GetSamples() {
+			if sample.Metric.Name == metrics.HTTPReqDurationName {
+				seen = true
+				closeReason, _ := sample.Tags.Get("close_reason")
+				assert.Equal(t, reason, closeReason)
+			}
+		}
+	}
+	assert.True(t, seen, "http_req_duration not emitted")
+}
+
 func TestErrors(t *testing.T) {
 	t.Parallel()
 
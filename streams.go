@@ -0,0 +1,53 @@
+package sse
+
+import (
+	"time"
+
+	"go.k6.io/k6/metrics"
+)
+
+// streamOpened counts the stream as active until streamClosed is called.
+func (c *Client) streamOpened() {
+	c.active = true
+	c.activeStreams.Add(1)
+}
+
+// streamClosed stops counting the stream as active and pushes the new number of active streams.
+func (c *Client) streamClosed(t time.Time) {
+	if !c.active {
+		return
+	}
+	c.active = false
+	c.pushActiveStreams(t, c.activeStreams.Add(-1))
+}
+
+// pushStreamMetrics pushes the in-flight metrics of the stream, the number of active streams
+// and the age of the stream, so dashboards reflect the open streams before they end.
+// The age is not pushed while the stream waits to reconnect.
+func (c *Client) pushStreamMetrics(t time.Time) {
+	c.pushActiveStreams(t, c.activeStreams.Load())
+	if !c.active {
+		return
+	}
+	metrics.PushIfNotDone(c.ctx, c.samplesOutput, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: c.sseMetrics.SSEStreamAge,
+			Tags:   c.tagsAndMeta.Tags,
+		},
+		Time:     t,
+		Metadata: c.tagsAndMeta.Metadata,
+		Value:    metrics.D(t.Sub(c.connStart)),
+	})
+}
+
+func (c *Client) pushActiveStreams(t time.Time, active int64) {
+	metrics.PushIfNotDone(c.ctx, c.samplesOutput, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: c.sseMetrics.SSEActiveStreams,
+			Tags:   c.tagsAndMeta.Tags,
+		},
+		Time:     t,
+		Metadata: c.tagsAndMeta.Metadata,
+		Value:    float64(active),
+	})
+}
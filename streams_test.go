@@ -0,0 +1,81 @@
+package sse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/metrics"
+)
+
+func TestStreamMetrics(t *testing.T) {
+	t.Parallel()
+
+	// streamSamples returns the active streams and stream ages pushed
+	streamSamples := func(test testState) ([]float64, []float64) {
+		var activeStreams, ages []float64
+		for _, sampleContainer := range metrics.GetBufferedSamples(test.samples) {
+			for _, sample := range sampleContainer.GetSamples() {
+				switch sample.Metric.Name {
+				case MetricActiveStreamsName:
+					activeStreams = append(activeStreams, sample.Value)
+				case MetricStreamAgeName:
+					ages = append(ages, sample.Value)
+				}
+			}
+		}
+		return activeStreams, ages
+	}
+
+	t.Run("open stream", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-flushed", sseFlushedHandler(t))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		sse.open("HTTPBIN_IP_URL/sse-flushed", {flushInterval: "20ms"}, function(client){});
+		`))
+		require.NoError(t, err)
+
+		// The streams are counted per k6 instance, each test has its own
+		activeStreams, ages := streamSamples(test)
+		require.Greater(t, len(activeStreams), 2)
+		for _, active := range activeStreams[:len(activeStreams)-1] {
+			assert.Equal(t, float64(1), active)
+		}
+		assert.Equal(t, float64(0), activeStreams[len(activeStreams)-1], "the stream is no longer active once closed")
+
+		require.Greater(t, len(ages), 1)
+		assert.IsIncreasing(t, ages)
+	})
+
+	t.Run("waiting to reconnect", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-flushed", sseFlushedHandler(t))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var events = 0
+		sse.open("HTTPBIN_IP_URL/sse-flushed", {flushInterval: "20ms", reconnect: {delay: "100ms"}}, function(client){
+			client.on("event", function() {
+				if (++events == 6) {
+					client.close()
+				}
+			})
+		});
+		`))
+		require.NoError(t, err)
+
+		activeStreams, ages := streamSamples(test)
+		open := 0
+		for _, active := range activeStreams {
+			if active == 1 {
+				open++
+			}
+		}
+		assert.Contains(t, activeStreams[:len(activeStreams)-1], float64(0), "the stream is not active while waiting to reconnect")
+		assert.Len(t, ages, open, "the age is only pushed while the stream is open")
+	})
+}
@@ -0,0 +1,130 @@
+package sse
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/sobek"
+	"go.k6.io/k6/metrics"
+)
+
+// Defaults of the retryAfter param.
+const (
+	defaultRetryAfterMaxWait    = 30 * time.Second
+	defaultRetryAfterMaxRetries = 3
+)
+
+type retryAfterOptions struct {
+	maxWait    time.Duration
+	maxRetries int64
+}
+
+// isThrottled returns true if the server rejected the connection with 429 or 503.
+func isThrottled(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// parseRetryAfter parses the Retry-After header, either delay seconds or an HTTP date.
+// It returns -1 if the header is missing or invalid.
+func parseRetryAfter(retryAfter string, now time.Time) time.Duration {
+	retryAfter = strings.TrimSpace(retryAfter)
+	if retryAfter == "" {
+		return -1
+	}
+	if seconds, err := strconv.ParseInt(retryAfter, 10, 64); err == nil {
+		if seconds < 0 {
+			return -1
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(retryAfter); err == nil {
+		return max(date.Sub(now), 0)
+	}
+	return -1
+}
+
+// checkThrottled records whether the response throttles the connection and pushes sse_throttled if so.
+func (c *Client) checkThrottled(resp *http.Response, t time.Time) {
+	c.throttled = isThrottled(resp)
+	c.retryAfter = -1
+	if !c.throttled {
+		return
+	}
+	c.retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), t)
+
+	metrics.PushIfNotDone(c.ctx, c.samplesOutput, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: c.sseMetrics.SSEThrottled,
+			Tags:   c.tagsAndMeta.Tags,
+		},
+		Time:     t,
+		Metadata: c.tagsAndMeta.Metadata,
+		Value:    1,
+	})
+}
+
+// connectWithRetryAfter connects, and connects again after the Retry-After delay, bounded by the max wait,
+// as long as the server throttles the connection and the retries of the retryAfter param are not exhausted.
+func (c *Client) connectWithRetryAfter() (func(), error) {
+	opts := c.args.retryAfter
+	for retries := int64(0); ; retries++ {
+		connEndHook, err := c.connect()
+		if err != nil || opts == nil || !c.throttled || c.retryAfter < 0 || retries >= opts.maxRetries {
+			return connEndHook, err
+		}
+
+		_ = c.closeWith(closeReasonThrottled)
+		connEndHook()
+		c.tracer.end()
+
+		wait := time.NewTimer(min(c.retryAfter, opts.maxWait))
+		select {
+		case <-wait.C:
+		case <-c.ctx.Done():
+			wait.Stop()
+			return func() {}, c.ctx.Err()
+		}
+	}
+}
+
+func parseRetryAfterOptions(rt *sobek.Runtime, retryAfterV sobek.Value) (*retryAfterOptions, error) {
+	opts := &retryAfterOptions{
+		maxWait:    defaultRetryAfterMaxWait,
+		maxRetries: defaultRetryAfterMaxRetries,
+	}
+
+	// retryAfter: true uses the defaults
+	if _, ok := retryAfterV.Export().(bool); ok {
+		return opts, nil
+	}
+
+	retryAfterObj := retryAfterV.ToObject(rt)
+	for _, k := range retryAfterObj.Keys() {
+		v := retryAfterObj.Get(k)
+		if sobek.IsUndefined(v) || sobek.IsNull(v) {
+			continue
+		}
+		switch k {
+		case "maxWait":
+			d, err := time.ParseDuration(v.String())
+			if err != nil {
+				return nil, fmt.Errorf("invalid maxWait: %w", err)
+			}
+			if d < 0 {
+				return nil, errors.New("invalid maxWait: must be positive")
+			}
+			opts.maxWait = d
+		case "maxRetries":
+			opts.maxRetries = v.ToInteger()
+			if opts.maxRetries < 0 {
+				return nil, errors.New("invalid maxRetries: must be positive")
+			}
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,138 @@
+package sse
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/metrics"
+)
+
+// sseThrottledHandler rejects the first connections with the status and Retry-After header.
+func sseThrottledHandler(connections *atomic.Int64, rejected int64, status int, retryAfter string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if connections.Add(1) <= rejected {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: accepted\n\n"))
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 120*time.Second, parseRetryAfter("120", now))
+	assert.Equal(t, 90*time.Second, parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(now.Add(-time.Hour).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(-1), parseRetryAfter("", now))
+	assert.Equal(t, time.Duration(-1), parseRetryAfter("-1", now))
+	assert.Equal(t, time.Duration(-1), parseRetryAfter("soon", now))
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	collect := func(test testState) (throttled float64, statuses, reasons []string) {
+		for _, sampleContainer := range metrics.GetBufferedSamples(test.samples) {
+			for _, sample := range sampleContainer.GetSamples() {
+				switch sample.Metric.Name {
+				case MetricThrottledName:
+					throttled += sample.Value
+				case metrics.HTTPReqsName:
+					status, _ := sample.Tags.Get(metrics.TagStatus.String())
+					statuses = append(statuses, status)
+				case metrics.HTTPReqDurationName:
+					reason, _ := sample.Tags.Get("close_reason")
+					reasons = append(reasons, reason)
+				}
+			}
+		}
+		return throttled, statuses, reasons
+	}
+
+	t.Run("retried once throttled", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		var connections atomic.Int64
+		test.tb.Mux.Handle("/sse-throttled", sseThrottledHandler(&connections, 2, http.StatusTooManyRequests, "0"))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var events = []
+		var response = sse.open("HTTPBIN_IP_URL/sse-throttled", {retryAfter: true}, function(client){
+			client.on("event", function(event) { events.push(event.data) })
+		})
+		if (response.status != 200 || response.throttled) {
+			throw new Error("unexpected response: " + JSON.stringify(response))
+		}
+		if (events.join() != "accepted") {
+			throw new Error("unexpected events: " + events.join())
+		}
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), connections.Load())
+
+		throttled, statuses, reasons := collect(test)
+		assert.Equal(t, float64(2), throttled)
+		assert.Equal(t, []string{"429", "429", "200"}, statuses)
+		assert.Equal(t, []string{closeReasonThrottled, closeReasonThrottled, closeReasonServer}, reasons)
+	})
+
+	t.Run("not retried by default", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		var connections atomic.Int64
+		test.tb.Mux.Handle("/sse-throttled", sseThrottledHandler(&connections, 1, http.StatusServiceUnavailable, "2"))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var response = sse.open("HTTPBIN_IP_URL/sse-throttled", function(client){})
+		if (response.status != 503 || !response.throttled || response.retry_after != 2000) {
+			throw new Error("unexpected response: " + JSON.stringify(response))
+		}
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), connections.Load())
+
+		throttled, _, _ := collect(test)
+		assert.Equal(t, float64(1), throttled)
+	})
+
+	t.Run("bounded by the max wait and retries", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		var connections atomic.Int64
+		test.tb.Mux.Handle("/sse-throttled", sseThrottledHandler(&connections, 10, http.StatusTooManyRequests, "3600"))
+
+		start := time.Now()
+		_, err := test.VU.Runtime().RunString(sr(`
+		var response = sse.open("HTTPBIN_IP_URL/sse-throttled", {retryAfter: {maxWait: "10ms", maxRetries: 2}}, function(client){})
+		if (response.status != 429 || response.retry_after != 3600000) {
+			throw new Error("unexpected response: " + JSON.stringify(response))
+		}
+		`))
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, int64(3), connections.Load())
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		_, err := test.VU.Runtime().RunString(sr(`sse.open("HTTPBIN_IP_URL/sse", {retryAfter: {maxWait: "-1s"}}, function(client){});`))
+		require.ErrorContains(t, err, "invalid sse.open() retryAfter: invalid maxWait: must be positive")
+
+		_, err = test.VU.Runtime().RunString(sr(`sse.open("HTTPBIN_IP_URL/sse", {retryAfter: {maxRetries: -1}}, function(client){});`))
+		require.ErrorContains(t, err, "invalid sse.open() retryAfter: invalid maxRetries: must be positive")
+	})
+}
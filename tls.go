@@ -0,0 +1,160 @@
+package sse
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/sobek"
+	"go.k6.io/k6/lib"
+	"gopkg.in/guregu/null.v3"
+)
+
+// tlsOptions overrides the VU TLS configuration for a single stream.
+type tlsOptions struct {
+	insecureSkipVerify *bool
+	minVersion         uint16
+	maxVersion         uint16
+	cipherSuites       []uint16
+	serverName         string
+	certificates       []*lib.TLSAuth
+}
+
+// apply returns a copy of the config with the options applied,
+// the client certificate is selected according to the host of the url requested.
+func (o *tlsOptions) apply(config *tls.Config, rawURL string) (*tls.Config, error) {
+	if o == nil {
+		return config, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Hostname()
+
+	if config == nil {
+		config = &tls.Config{} //nolint:gosec // versions are defaulted by crypto/tls or set by the user
+	} else {
+		config = config.Clone()
+	}
+
+	if o.insecureSkipVerify != nil {
+		config.InsecureSkipVerify = *o.insecureSkipVerify
+	}
+	if o.minVersion != 0 {
+		config.MinVersion = o.minVersion
+	}
+	if o.maxVersion != 0 {
+		config.MaxVersion = o.maxVersion
+	}
+	if len(o.cipherSuites) > 0 {
+		config.CipherSuites = o.cipherSuites
+	}
+	if o.serverName != "" {
+		config.ServerName = o.serverName
+	}
+
+	for _, auth := range o.certificates {
+		if !matchesDomains(host, auth.Domains) {
+			continue
+		}
+		cert, err := auth.Certificate()
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{*cert}
+		break
+	}
+
+	return config, nil
+}
+
+// matchesDomains returns true if the host matches one of the domains, which may contain wildcards
+// such as "*.example.com". A certificate without domains is presented to any host.
+func matchesDomains(host string, domains []string) bool {
+	if len(domains) == 0 {
+		return true
+	}
+	for _, domain := range domains {
+		if domain == host {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(domain, "*"); ok && strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTLSOptions(rt *sobek.Runtime, tlsV sobek.Value) (*tlsOptions, error) {
+	opts := &tlsOptions{}
+
+	tlsObj := tlsV.ToObject(rt)
+	for _, k := range tlsObj.Keys() {
+		v := tlsObj.Get(k)
+		if sobek.IsUndefined(v) || sobek.IsNull(v) {
+			continue
+		}
+		switch k {
+		case "insecureSkipVerify":
+			insecureSkipVerify := v.ToBoolean()
+			opts.insecureSkipVerify = &insecureSkipVerify
+		case "minVersion", "maxVersion":
+			version, ok := lib.SupportedTLSVersions[strings.TrimSpace(v.String())]
+			if !ok {
+				return nil, fmt.Errorf("unknown %s %q", k, v.String())
+			}
+			if k == "minVersion" {
+				opts.minVersion = uint16(version)
+			} else {
+				opts.maxVersion = uint16(version)
+			}
+		case "cipherSuites":
+			var names []string
+			if err := rt.ExportTo(v, &names); err != nil {
+				return nil, fmt.Errorf("invalid cipherSuites: %w", err)
+			}
+			for _, name := range names {
+				suite, ok := lib.SupportedTLSCipherSuites[name]
+				if !ok {
+					return nil, fmt.Errorf("unknown cipher suite %q", name)
+				}
+				opts.cipherSuites = append(opts.cipherSuites, suite)
+			}
+		case "serverName":
+			opts.serverName = strings.TrimSpace(v.String())
+		case "certificates":
+			var certificates []struct {
+				Cert     string   `js:"cert"`
+				Key      string   `js:"key"`
+				Password *string  `js:"password"`
+				Domains  []string `js:"domains"`
+			}
+			if err := rt.ExportTo(v, &certificates); err != nil {
+				return nil, fmt.Errorf("invalid certificates: %w", err)
+			}
+			for _, c := range certificates {
+				auth := &lib.TLSAuth{TLSAuthFields: lib.TLSAuthFields{
+					Cert:     c.Cert,
+					Key:      c.Key,
+					Password: null.StringFromPtr(c.Password),
+					Domains:  c.Domains,
+				}}
+				if _, err := auth.Certificate(); err != nil {
+					return nil, fmt.Errorf("invalid certificate: %w", err)
+				}
+				opts.certificates = append(opts.certificates, auth)
+			}
+		}
+	}
+
+	if opts.minVersion != 0 && opts.maxVersion != 0 && opts.minVersion > opts.maxVersion {
+		return nil, fmt.Errorf("minVersion %s is greater than maxVersion %s",
+			lib.SupportedTLSVersionsToString[lib.TLSVersion(opts.minVersion)],
+			lib.SupportedTLSVersionsToString[lib.TLSVersion(opts.maxVersion)])
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,191 @@
+package sse
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/metrics"
+)
+
+// newClientCertificate returns a self-signed client certificate and key PEM encoded.
+func newClientCertificate(t *testing.T) (*x509.Certificate, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "k6-sse-client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return cert, string(certPEM), string(keyPEM)
+}
+
+// newMTLSServer returns a TLS server requiring a client certificate signed by the given one.
+func newMTLSServer(t *testing.T, clientCA *x509.Certificate) *httptest.Server {
+	pool := x509.NewCertPool()
+	pool.AddCert(clientCA)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, err := w.Write([]byte("data: " + req.TLS.PeerCertificates[0].Subject.CommonName + "\n\n"))
+		require.NoError(t, err)
+	}))
+	srv.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+		MinVersion: tls.VersionTLS12,
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestTLSOptions(t *testing.T) {
+	t.Parallel()
+
+	clientCA, certPEM, keyPEM := newClientCertificate(t)
+	srv := newMTLSServer(t, clientCA)
+
+	t.Run("client certificate", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		test.VU.StateField.Options.SystemTags = metrics.NewSystemTagSet(metrics.TagURL, metrics.TagName, metrics.TagTLSVersion)
+
+		rt := test.VU.Runtime()
+		require.NoError(t, rt.Set("url", srv.URL+"/sse"))
+		require.NoError(t, rt.Set("cert", certPEM))
+		require.NoError(t, rt.Set("key", keyPEM))
+
+		_, err := rt.RunString(`
+		var data = null;
+		var res = sse.open(url, {
+			tls: {
+				insecureSkipVerify: true,
+				maxVersion: "tls1.2",
+				cipherSuites: ["TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"],
+				certificates: [
+					{cert: cert, key: key, domains: ["*.example.com"]},
+					{cert: cert, key: key, domains: ["127.0.0.1"]},
+				],
+			},
+		}, function(client){
+			client.on("event", function(event) {
+				data = event.data;
+			});
+		});
+		if (res.status !== 200 || data !== "k6-sse-client") {
+			throw new Error("unexpected response: " + res.status + " " + res.error + " " + data);
+		}
+		if (res.tls_version !== "tls1.2") {
+			throw new Error("unexpected tls version: " + res.tls_version);
+		}
+		if (res.tls_cipher_suite !== "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256") {
+			throw new Error("unexpected tls cipher suite: " + res.tls_cipher_suite);
+		}
+		`)
+		require.NoError(t, err)
+
+		seenTLSVersion := false
+		for _, sampleContainer := range metrics.GetBufferedSamples(test.samples) {
+			for _, sample := range sampleContainer.GetSamples() {
+				if sample.Metric.Name == MetricEventName {
+					version, _ := sample.Tags.Get(metrics.TagTLSVersion.String())
+					assert.Equal(t, "tls1.2", version)
+					seenTLSVersion = true
+				}
+			}
+		}
+		assert.True(t, seenTLSVersion, "no sse_event sample with tls_version tag")
+	})
+
+	t.Run("no matching certificate", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		test.VU.StateField.Options.Throw.Bool = false
+
+		rt := test.VU.Runtime()
+		require.NoError(t, rt.Set("url", srv.URL+"/sse"))
+		require.NoError(t, rt.Set("cert", certPEM))
+		require.NoError(t, rt.Set("key", keyPEM))
+
+		_, err := rt.RunString(`
+		var res = sse.open(url, {
+			tls: {
+				insecureSkipVerify: true,
+				certificates: [{cert: cert, key: key, domains: ["events.example.com"]}],
+			},
+		}, function(client){});
+		if (!res.error) {
+			throw new Error("expected the handshake to fail without client certificate");
+		}
+		`)
+		require.NoError(t, err)
+	})
+
+	t.Run("server certificate is verified", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		test.VU.StateField.Options.Throw.Bool = false
+
+		rt := test.VU.Runtime()
+		require.NoError(t, rt.Set("url", srv.URL+"/sse"))
+
+		_, err := rt.RunString(`
+		var res = sse.open(url, {tls: {serverName: "example.com"}}, function(client){});
+		if (res.error.indexOf("certificate") < 0) {
+			throw new Error("unexpected error: " + res.error);
+		}
+		`)
+		require.NoError(t, err)
+	})
+
+	testCases := []struct {
+		name   string
+		params string
+		err    string
+	}{
+		{name: "unknown version", params: `{minVersion: "tls2"}`, err: `unknown minVersion "tls2"`},
+		{name: "min greater than max", params: `{minVersion: "tls1.3", maxVersion: "tls1.2"}`, err: "minVersion tls1.3 is greater than maxVersion tls1.2"},
+		{name: "unknown cipher suite", params: `{cipherSuites: ["TLS_NOPE"]}`, err: `unknown cipher suite "TLS_NOPE"`},
+		{name: "invalid certificate", params: `{certificates: [{cert: "nope", key: "nope"}]}`, err: "invalid certificate"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			test := newTestState(t)
+			sr := test.tb.Replacer.Replace
+
+			_, err := test.VU.Runtime().RunString(sr(`
+			sse.open("HTTPBIN_IP_URL/sse", {tls: ` + tc.params + `}, function(client){});
+			`))
+			require.ErrorContains(t, err, "invalid sse.open() tls: "+tc.err)
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package sse
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/sobek"
+	"go.k6.io/k6/lib"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Supported trace context propagators.
+const (
+	propagatorW3C    = "w3c"
+	propagatorB3     = "b3"
+	propagatorJaeger = "jaeger"
+)
+
+// metadataTraceID is the sample metadata key holding the propagated trace id,
+// it is the same key used by k6 http tracing instrumentation.
+const metadataTraceID = "trace_id"
+
+// tracerName is the name of the OpenTelemetry tracer used for stream spans.
+const tracerName = "k6/x/sse"
+
+type tracingOptions struct {
+	propagator    string
+	sampled       bool
+	traceState    string
+	spans         bool
+	eventInterval int64
+}
+
+// streamTracer propagates a trace context on the sse request and optionally
+// records an OpenTelemetry span covering the stream lifetime.
+type streamTracer struct {
+	opts    *tracingOptions
+	traceID trace.TraceID
+	spanID  trace.SpanID
+	span    trace.Span
+	events  int64
+}
+
+// newStreamTracer creates the trace context of a stream. If spans are enabled and
+// k6 provides a tracer, the ids of the stream span are propagated, random ids otherwise.
+func newStreamTracer(ctx context.Context, state *lib.State, opts *tracingOptions) (*streamTracer, error) {
+	t := &streamTracer{opts: opts}
+
+	if opts.spans && state.TracerProvider != nil {
+		_, t.span = state.TracerProvider.Tracer(tracerName).Start(ctx, "sse.open",
+			trace.WithSpanKind(trace.SpanKindClient))
+		if sc := t.span.SpanContext(); sc.IsValid() {
+			t.traceID = sc.TraceID()
+			t.spanID = sc.SpanID()
+			return t, nil
+		}
+	}
+
+	if _, err := rand.Read(t.traceID[:]); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(t.spanID[:]); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// inject sets the trace context headers of the configured propagator.
+func (t *streamTracer) inject(header http.Header) {
+	switch t.opts.propagator {
+	case propagatorB3:
+		sampled := "0"
+		if t.opts.sampled {
+			sampled = "1"
+		}
+		header.Set("b3", fmt.Sprintf("%s-%s-%s", t.traceID, t.spanID, sampled))
+	case propagatorJaeger:
+		flags := "0"
+		if t.opts.sampled {
+			flags = "1"
+		}
+		header.Set("uber-trace-id", fmt.Sprintf("%s:%s:0:%s", t.traceID, t.spanID, flags))
+	default:
+		flags := "00"
+		if t.opts.sampled {
+			flags = "01"
+		}
+		header.Set("traceparent", fmt.Sprintf("00-%s-%s-%s", t.traceID, t.spanID, flags))
+		if t.opts.traceState != "" {
+			header.Set("tracestate", t.opts.traceState)
+		}
+	}
+}
+
+// firstByte records the reception of the first response byte.
+func (t *streamTracer) firstByte() {
+	if t == nil || t.span == nil {
+		return
+	}
+	t.span.AddEvent("first_byte")
+}
+
+// event records a span event every configured interval of received events.
+func (t *streamTracer) event() {
+	if t == nil {
+		return
+	}
+	t.events++
+	if t.span == nil || t.opts.eventInterval <= 0 || t.events%t.opts.eventInterval != 0 {
+		return
+	}
+	t.span.AddEvent("event", trace.WithAttributes(attribute.Int64("sse.events", t.events)))
+}
+
+// fail marks the stream span as failed.
+func (t *streamTracer) fail(err error) {
+	if t == nil || t.span == nil {
+		return
+	}
+	t.span.RecordError(err)
+	t.span.SetStatus(codes.Error, err.Error())
+}
+
+// end records the close of the stream and ends the span.
+func (t *streamTracer) end() {
+	if t == nil || t.span == nil {
+		return
+	}
+	t.span.AddEvent("close", trace.WithAttributes(attribute.Int64("sse.events", t.events)))
+	t.span.End()
+}
+
+func parseTracingOptions(rt *sobek.Runtime, tracingV sobek.Value) (*tracingOptions, error) {
+	opts := &tracingOptions{
+		propagator: propagatorW3C,
+		sampled:    true,
+	}
+
+	tracingObj := tracingV.ToObject(rt)
+	for _, k := range tracingObj.Keys() {
+		v := tracingObj.Get(k)
+		if sobek.IsUndefined(v) || sobek.IsNull(v) {
+			continue
+		}
+		switch k {
+		case "propagator":
+			propagator := strings.ToLower(strings.TrimSpace(v.String()))
+			switch propagator {
+			case propagatorW3C, propagatorB3, propagatorJaeger:
+				opts.propagator = propagator
+			default:
+				return nil, fmt.Errorf("unknown propagator %q", propagator)
+			}
+		case "sampled":
+			opts.sampled = v.ToBoolean()
+		case "tracestate":
+			opts.traceState = v.String()
+		case "spans":
+			opts.spans = v.ToBoolean()
+		case "eventInterval":
+			opts.eventInterval = v.ToInteger()
+			if opts.eventInterval < 0 {
+				return nil, errors.New("eventInterval must be positive")
+			}
+		}
+	}
+
+	return opts, nil
+}
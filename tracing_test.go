@@ -0,0 +1,156 @@
+package sse
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/metrics"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	t.Parallel()
+
+	echoTraceHeaders := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		for _, h := range []string{"Traceparent", "Tracestate", "B3", "Uber-Trace-Id"} {
+			if v := req.Header.Get(h); v != "" {
+				w.Header().Set("Echo-"+h, v)
+			}
+		}
+		_, err := w.Write([]byte("data: first\n\ndata: second\n\ndata: third\n\n"))
+		require.NoError(t, err)
+	})
+
+	t.Run("w3c", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-trace", echoTraceHeaders)
+
+		v, err := test.VU.Runtime().RunString(sr(`
+		var res = sse.open("HTTPBIN_IP_URL/sse-trace", {tracing: {tracestate: "k6=sse"}}, function(client){});
+		if (res.headers["Echo-Tracestate"] !== "k6=sse") {
+			throw new Error("unexpected tracestate: " + res.headers["Echo-Tracestate"]);
+		}
+		res.headers["Echo-Traceparent"];
+		`))
+		require.NoError(t, err)
+
+		traceparent := v.String()
+		assert.Regexp(t, regexp.MustCompile(`^00-[0-9a-f]{32}-[0-9a-f]{16}-01$`), traceparent)
+
+		samplesBuf := metrics.GetBufferedSamples(test.samples)
+		require.NotEmpty(t, samplesBuf)
+		for _, sampleContainer := range samplesBuf {
+			for _, sample := range sampleContainer.GetSamples() {
+				assert.Equal(t, traceparent[3:35], sample.Metadata[metadataTraceID])
+			}
+		}
+	})
+
+	t.Run("reconnect", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-traceparent", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, err := w.Write([]byte("data: " + req.Header.Get("Traceparent") + "\n\n"))
+			require.NoError(t, err)
+		}))
+
+		v, err := test.VU.Runtime().RunString(sr(`
+		var traceparents = []
+		sse.open("HTTPBIN_IP_URL/sse-traceparent", {tracing: {}, reconnect: {delay: "10ms"}}, function(client){
+			client.on("event", function(event) {
+				traceparents.push(event.data)
+				if (traceparents.length == 2) {
+					client.close()
+				}
+			})
+		});
+		traceparents.join()
+		`))
+		require.NoError(t, err)
+
+		traceparents := strings.Split(v.String(), ",")
+		require.Len(t, traceparents, 2)
+		require.NotEqual(t, traceparents[0], traceparents[1])
+
+		// Each connection keeps its trace id once the next one is opened
+		var traceIDs []string
+		for _, sampleContainer := range metrics.GetBufferedSamples(test.samples) {
+			for _, sample := range sampleContainer.GetSamples() {
+				if sample.Metric.Name == metrics.HTTPReqsName {
+					traceIDs = append(traceIDs, sample.Metadata[metadataTraceID])
+				}
+			}
+		}
+		assert.Equal(t, []string{traceparents[0][3:35], traceparents[1][3:35]}, traceIDs)
+	})
+
+	t.Run("b3 and jaeger", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-trace", echoTraceHeaders)
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var res = sse.open("HTTPBIN_IP_URL/sse-trace", {tracing: {propagator: "b3", sampled: false}}, function(client){});
+		if (!/^[0-9a-f]{32}-[0-9a-f]{16}-0$/.test(res.headers["Echo-B3"])) {
+			throw new Error("unexpected b3 header: " + res.headers["Echo-B3"]);
+		}
+		if (res.headers["Echo-Traceparent"] !== undefined) {
+			throw new Error("unexpected traceparent header");
+		}
+		res = sse.open("HTTPBIN_IP_URL/sse-trace", {tracing: {propagator: "jaeger"}}, function(client){});
+		if (!/^[0-9a-f]{32}:[0-9a-f]{16}:0:1$/.test(res.headers["Echo-Uber-Trace-Id"])) {
+			throw new Error("unexpected jaeger header: " + res.headers["Echo-Uber-Trace-Id"]);
+		}
+		`))
+		require.NoError(t, err)
+	})
+
+	t.Run("spans", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-trace", echoTraceHeaders)
+
+		recorder := tracetest.NewSpanRecorder()
+		test.VU.StateField.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+		v, err := test.VU.Runtime().RunString(sr(`
+		var res = sse.open("HTTPBIN_IP_URL/sse-trace", {tracing: {spans: true, eventInterval: 2}}, function(client){});
+		res.headers["Echo-Traceparent"];
+		`))
+		require.NoError(t, err)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		span := spans[0]
+		assert.Equal(t, "sse.open", span.Name())
+		assert.Equal(t, "00-"+span.SpanContext().TraceID().String()+"-"+span.SpanContext().SpanID().String()+"-01", v.String())
+
+		var names []string
+		for _, event := range span.Events() {
+			names = append(names, event.Name)
+		}
+		assert.Equal(t, []string{"first_byte", "event", "close"}, names)
+	})
+
+	t.Run("invalid propagator", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var res = sse.open("HTTPBIN_IP_URL/sse", {tracing: {propagator: "unknown"}}, function(client){});
+		`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid sse.open() tracing")
+	})
+}
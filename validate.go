@@ -0,0 +1,96 @@
+package sse
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grafana/sobek"
+	"go.k6.io/k6/metrics"
+)
+
+// validateStrict is the validate param value recording the deviations from the SSE specification.
+const validateStrict = "strict"
+
+// Rules of the SSE specification checked in strict validation, set as the rule tag of sse_protocol_violations.
+const (
+	ruleContentType       = "content_type"
+	ruleCacheControl      = "cache_control"
+	ruleMixedLineEndings  = "mixed_line_endings"
+	ruleBOMMidStream      = "bom_mid_stream"
+	ruleInvalidRetry      = "invalid_retry"
+	ruleUnknownField      = "unknown_field"
+	ruleUnterminatedEvent = "unterminated_event"
+)
+
+// maxReportedViolations bounds the violations kept in the report, all of them are counted in the metric.
+const maxReportedViolations = 100
+
+// ValidationReport is the report of the strict validation of a stream, returned on the response.
+type ValidationReport struct {
+	Valid      bool                `json:"valid"`
+	Violations []ProtocolViolation `json:"violations"`
+	// Truncated is set once more violations than the report keeps were found
+	Truncated bool `json:"truncated"`
+}
+
+// ProtocolViolation is a deviation from the SSE specification, at the line of the stream it was found,
+// or at line 0 for the response headers.
+type ProtocolViolation struct {
+	Rule    string `json:"rule"`
+	Line    int64  `json:"line"`
+	Message string `json:"message"`
+}
+
+// validateHeaders records the violations of the response headers.
+func (c *Client) validateHeaders(resp *http.Response) {
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType != "text/event-stream" {
+		c.recordViolation(ProtocolViolation{
+			Rule:    ruleContentType,
+			Message: fmt.Sprintf("Content-Type is %q instead of text/event-stream", contentType),
+		})
+	}
+	if resp.Header.Get("Cache-Control") == "" {
+		c.recordViolation(ProtocolViolation{
+			Rule:    ruleCacheControl,
+			Message: "Cache-Control is missing, intermediaries may cache the stream",
+		})
+	}
+}
+
+// recordViolation adds the violation to the report and pushes sse_protocol_violations tagged with its rule.
+func (c *Client) recordViolation(v ProtocolViolation) {
+	if c.validation == nil {
+		return
+	}
+	c.validation.Valid = false
+	if len(c.validation.Violations) < maxReportedViolations {
+		c.validation.Violations = append(c.validation.Violations, v)
+	} else {
+		c.validation.Truncated = true
+	}
+
+	metrics.PushIfNotDone(c.ctx, c.samplesOutput, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: c.sseMetrics.SSEProtocolViolations,
+			Tags:   c.tagsAndMeta.Tags.With("rule", v.Rule),
+		},
+		Time:     time.Now(),
+		Metadata: c.tagsAndMeta.Metadata,
+		Value:    1,
+	})
+}
+
+func parseValidate(v sobek.Value) (bool, error) {
+	switch validate := strings.TrimSpace(v.String()); validate {
+	case validateStrict:
+		return true, nil
+	case "", "off":
+		return false, nil
+	default:
+		return false, fmt.Errorf("unknown mode %q", validate)
+	}
+}
@@ -0,0 +1,196 @@
+package sse
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/metrics"
+)
+
+func TestParserValidation(t *testing.T) {
+	t.Parallel()
+
+	// violations returns the violations found in the stream, and the data of its events
+	violations := func(t *testing.T, stream string) ([]ProtocolViolation, []string) {
+		p := newEventParser(strings.NewReader(stream), defaultParserLimits)
+		defer p.release()
+		p.validate = true
+
+		var found []ProtocolViolation
+		var data []string
+		for {
+			tok, err := p.next()
+			if errors.Is(err, io.EOF) {
+				return found, data
+			}
+			require.NoError(t, err)
+			switch tok.kind {
+			case tokenViolation:
+				found = append(found, tok.violation)
+			case tokenEvent:
+				data = append(data, tok.event.Data)
+			}
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		stream   string
+		expected []ProtocolViolation
+		data     []string
+	}{
+		"compliant": {
+			stream: "\uFEFFid: 1\ndata: x\nretry: 10\n\n: keepalive\n\n",
+			data:   []string{"x"},
+		},
+		"mixed line endings": {
+			stream:   "data: a\r\n\r\ndata: b\n\ndata: c\r\r",
+			expected: []ProtocolViolation{{Rule: ruleMixedLineEndings, Line: 3}},
+			data:     []string{"a", "b", "c"},
+		},
+		"bom mid stream": {
+			stream:   "data: a\n\ndata: \uFEFFb\n\n",
+			expected: []ProtocolViolation{{Rule: ruleBOMMidStream, Line: 3}},
+			data:     []string{"a", "\uFEFFb"},
+		},
+		"invalid retry": {
+			stream:   "retry: 1s\ndata: a\n\n",
+			expected: []ProtocolViolation{{Rule: ruleInvalidRetry, Line: 1}},
+			data:     []string{"a"},
+		},
+		"unknown field": {
+			stream:   "data: a\nfoo: bar\n\n",
+			expected: []ProtocolViolation{{Rule: ruleUnknownField, Line: 2}},
+			data:     []string{"a"},
+		},
+		"unterminated event": {
+			stream:   "data: a\n\ndata: b\n",
+			expected: []ProtocolViolation{{Rule: ruleUnterminatedEvent, Line: 4}},
+			data:     []string{"a"},
+		},
+		"unterminated line": {
+			stream:   "data: a\n\ndata: b",
+			expected: []ProtocolViolation{{Rule: ruleUnterminatedEvent, Line: 3}},
+			data:     []string{"a"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			found, data := violations(t, tc.stream)
+			require.Len(t, found, len(tc.expected))
+			for i, v := range found {
+				assert.Equal(t, tc.expected[i].Rule, v.Rule)
+				assert.Equal(t, tc.expected[i].Line, v.Line)
+				assert.NotEmpty(t, v.Message)
+			}
+			assert.Equal(t, tc.data, data)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("violations", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-non-compliant", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("data: a\r\n\r\nfoo: bar\ndata: b\n\nretry: soon\ndata: c"))
+		}))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var events = [], errors = []
+		var response = sse.open("HTTPBIN_IP_URL/sse-non-compliant", {validate: "strict"}, function(client){
+			client.on("event", function(event) { events.push(event.data) })
+			client.on("error", function(e) { errors.push(e.error()) })
+		})
+		if (events.join() != "a,b" || errors.length != 0) {
+			throw new Error("unexpected events: " + events.join() + " " + errors.join())
+		}
+		var report = response.validation
+		if (report.valid || report.truncated) {
+			throw new Error("unexpected report: " + JSON.stringify(report))
+		}
+		var rules = report.violations.map(function(v) { return v.rule + "@" + v.line })
+		if (rules.join() != "content_type@0,cache_control@0,mixed_line_endings@3,unknown_field@3,invalid_retry@6,unterminated_event@7") {
+			throw new Error("unexpected violations: " + rules.join())
+		}
+		`))
+		require.NoError(t, err)
+
+		rules := map[string]float64{}
+		for _, sampleContainer := range metrics.GetBufferedSamples(test.samples) {
+			for _, sample := range sampleContainer.GetSamples() {
+				if sample.Metric.Name == MetricProtocolViolationsName {
+					rule, _ := sample.Tags.Get("rule")
+					rules[rule] += sample.Value
+				}
+			}
+		}
+		assert.Equal(t, map[string]float64{
+			ruleContentType:       1,
+			ruleCacheControl:      1,
+			ruleMixedLineEndings:  1,
+			ruleUnknownField:      1,
+			ruleInvalidRetry:      1,
+			ruleUnterminatedEvent: 1,
+		}, rules)
+	})
+
+	t.Run("compliant", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		test.tb.Mux.Handle("/sse-compliant", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+			w.Header().Set("Cache-Control", "no-store")
+			_, _ = w.Write([]byte("data: a\n\n"))
+		}))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var response = sse.open("HTTPBIN_IP_URL/sse-compliant", {validate: "strict"}, function(client){})
+		if (!response.validation.valid || response.validation.violations.length != 0) {
+			throw new Error("unexpected report: " + JSON.stringify(response.validation))
+		}
+		response = sse.open("HTTPBIN_IP_URL/sse-compliant", function(client){})
+		if (response.validation != null) {
+			throw new Error("the stream is validated without the validate param")
+		}
+		`))
+		require.NoError(t, err)
+	})
+
+	t.Run("rejected responses", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+		var connections atomic.Int64
+		test.tb.Mux.Handle("/sse-throttled", sseThrottledHandler(&connections, 2, http.StatusTooManyRequests, "0"))
+
+		_, err := test.VU.Runtime().RunString(sr(`
+		var response = sse.open("HTTPBIN_IP_URL/sse-throttled", {validate: "strict", retryAfter: true}, function(client){})
+		var rules = response.validation.violations.map(function(v) { return v.rule + "@" + v.line })
+		if (response.status != 200 || rules.join() != "cache_control@0") {
+			throw new Error("unexpected violations: " + rules.join())
+		}
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), connections.Load())
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		t.Parallel()
+		test := newTestState(t)
+		sr := test.tb.Replacer.Replace
+
+		_, err := test.VU.Runtime().RunString(sr(`sse.open("HTTPBIN_IP_URL/sse", {validate: "lenient"}, function(client){});`))
+		require.ErrorContains(t, err, `invalid sse.open() validate: unknown mode "lenient"`)
+	})
+}